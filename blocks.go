@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+)
+
+// RelationshipEntry is a user the caller has blocked or muted.
+type RelationshipEntry struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// relationshipTarget authenticates the request and parses the user in the path
+// for the block and mute endpoints. It writes the error response itself when
+// it returns false.
+func (cfg *apiConfig) relationshipTarget(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+
+	targetID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id")
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+
+	if targetID == userID {
+		respondWithError(w, http.StatusBadRequest, "can't target yourself")
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+
+	return userID, targetID, true
+}
+
+func (cfg *apiConfig) blockUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, blockedID, ok := cfg.relationshipTarget(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := cfg.dbQueries.GetUserByID(r.Context(), blockedID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "couldn't find user")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to block user")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	_, err = qtx.CreateBlock(r.Context(), database.CreateBlockParams{BlockerID: userID, BlockedID: blockedID})
+	if err == nil {
+		err = removeFollow(r.Context(), qtx, userID, blockedID)
+	}
+	if err == nil {
+		err = removeFollow(r.Context(), qtx, blockedID, userID)
+	}
+	if err == nil {
+		_, err = qtx.DeleteFollowRequest(r.Context(), database.DeleteFollowRequestParams{RequesterID: userID, TargetID: blockedID})
+	}
+	if err == nil {
+		_, err = qtx.DeleteFollowRequest(r.Context(), database.DeleteFollowRequestParams{RequesterID: blockedID, TargetID: userID})
+	}
+	if err == nil {
+		err = qtx.RemoveFromOwnersLists(r.Context(), database.RemoveFromOwnersListsParams{OwnerID: userID, UserID: blockedID})
+	}
+	if err == nil {
+		err = qtx.RemoveFromOwnersLists(r.Context(), database.RemoveFromOwnersListsParams{OwnerID: blockedID, UserID: userID})
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to block user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unblockUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, blockedID, ok := cfg.relationshipTarget(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := cfg.dbQueries.DeleteBlock(r.Context(), database.DeleteBlockParams{BlockerID: userID, BlockedID: blockedID})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to unblock user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) muteUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, mutedID, ok := cfg.relationshipTarget(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := cfg.dbQueries.GetUserByID(r.Context(), mutedID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "couldn't find user")
+		return
+	}
+
+	_, err = cfg.dbQueries.CreateMute(r.Context(), database.CreateMuteParams{MuterID: userID, MutedID: mutedID})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to mute user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unmuteUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, mutedID, ok := cfg.relationshipTarget(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := cfg.dbQueries.DeleteMute(r.Context(), database.DeleteMuteParams{MuterID: userID, MutedID: mutedID})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to unmute user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) getBlockedUsersHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetBlockedUsersParams{
+		UserID:          userID,
+		BeforeCreatedAt: after.CreatedAt,
+		BeforeID:        after.ID,
+		PageSize:        limit,
+	}
+	blocked, err := cfg.dbQueries.GetBlockedUsers(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get blocked users")
+		return
+	}
+
+	resp := Page[RelationshipEntry]{Items: []RelationshipEntry{}}
+	for _, entry := range blocked {
+		resp.Items = append(resp.Items, RelationshipEntry{UserID: entry.UserID, CreatedAt: entry.CreatedAt})
+	}
+	if len(blocked) > 0 {
+		last := blocked[len(blocked)-1]
+		resp.NextCursor = nextCursor(len(blocked), limit, cursor{CreatedAt: last.CreatedAt, ID: last.UserID})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) getMutedUsersHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetMutedUsersParams{
+		UserID:          userID,
+		BeforeCreatedAt: after.CreatedAt,
+		BeforeID:        after.ID,
+		PageSize:        limit,
+	}
+	muted, err := cfg.dbQueries.GetMutedUsers(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get muted users")
+		return
+	}
+
+	resp := Page[RelationshipEntry]{Items: []RelationshipEntry{}}
+	for _, entry := range muted {
+		resp.Items = append(resp.Items, RelationshipEntry{UserID: entry.UserID, CreatedAt: entry.CreatedAt})
+	}
+	if len(muted) > 0 {
+		last := muted[len(muted)-1]
+		resp.NextCursor = nextCursor(len(muted), limit, cursor{CreatedAt: last.CreatedAt, ID: last.UserID})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+)
+
+// Bookmark is a chirp the user saved. Bookmarks are only visible to the user
+// who made them.
+type Bookmark struct {
+	Chirp
+	BookmarkedAt time.Time `json:"bookmarked_at"`
+}
+
+func (cfg *apiConfig) bookmarkChirpHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id")
+		return
+	}
+
+	chirp, err := cfg.dbQueries.GetSingleChirp(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "no chirp found")
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to bookmark chirp")
+		return
+	}
+
+	visible, err := cfg.canViewChirp(r.Context(), chirp, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to bookmark chirp")
+		return
+	}
+
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "no chirp found")
+		return
+	}
+
+	_, err = cfg.dbQueries.CreateBookmark(r.Context(), database.CreateBookmarkParams{UserID: userID, ChirpID: chirp.ID})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to bookmark chirp")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unbookmarkChirpHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id")
+		return
+	}
+
+	_, err = cfg.dbQueries.DeleteBookmark(r.Context(), database.DeleteBookmarkParams{UserID: userID, ChirpID: chirpID})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to remove bookmark")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getBookmarksHandler lists the user's bookmarks, most recently saved first.
+// Chirps the user can no longer see are left out.
+func (cfg *apiConfig) getBookmarksHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetBookmarksParams{
+		UserID:          userID,
+		BeforeCreatedAt: after.CreatedAt,
+		BeforeID:        after.ID,
+		PageSize:        limit,
+	}
+	bookmarks, err := cfg.dbQueries.GetBookmarks(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get bookmarks")
+		return
+	}
+
+	chirps := []database.Chirp{}
+	for _, bookmark := range bookmarks {
+		chirps = append(chirps, bookmark.Chirp)
+	}
+
+	items, err := cfg.chirpResponses(r.Context(), chirps, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get bookmarks")
+		return
+	}
+
+	resp := Page[Bookmark]{Items: []Bookmark{}}
+	for i, item := range items {
+		resp.Items = append(resp.Items, Bookmark{Chirp: item, BookmarkedAt: bookmarks[i].BookmarkedAt})
+	}
+	if len(bookmarks) > 0 {
+		last := bookmarks[len(bookmarks)-1]
+		resp.NextCursor = nextCursor(len(bookmarks), limit, cursor{CreatedAt: last.BookmarkedAt, ID: last.Chirp.ID})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
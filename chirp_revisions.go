@@ -0,0 +1,228 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/moderation"
+)
+
+// How long after posting an author may still edit a chirp.
+const (
+	chirpEditWindow          = 15 * time.Minute
+	chirpyRedChirpEditWindow = 2 * time.Hour
+)
+
+type ChirpRevision struct {
+	ID        uuid.UUID `json:"id"`
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Body      string    `json:"body"`
+}
+
+func (cfg *apiConfig) updateChirpHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id")
+		return
+	}
+
+	data := struct {
+		Body string `json:"body"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	if utf8.RuneCountInString(data.Body) > maxChirpLength {
+		respondWithError(w, http.StatusBadRequest, "Chirp is too long")
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't find user")
+		return
+	}
+
+	if isSuspended(user) {
+		respondWithError(w, http.StatusForbidden, "account is suspended")
+		return
+	}
+
+	editWindow := chirpEditWindow
+	if user.IsChirpyRed {
+		editWindow = chirpyRedChirpEditWindow
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to update chirp")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirp, err := qtx.GetSingleChirpForUpdate(r.Context(), chirpID)
+	if err == nil && chirp.Status != chirpPublished {
+		// Drafts are edited through /api/drafts.
+		err = sql.ErrNoRows
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "no chirp found")
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to update chirp")
+		return
+	}
+
+	if chirp.UserID.UUID != userID {
+		respondWithError(w, http.StatusForbidden, "invalid author")
+		return
+	}
+
+	if chirp.ModerationStatus == chirpHidden {
+		respondWithError(w, http.StatusForbidden, "chirp was hidden by a moderator")
+		return
+	}
+
+	if time.Since(chirp.CreatedAt) > editWindow {
+		respondWithError(w, http.StatusForbidden, "edit window has closed")
+		return
+	}
+
+	result := cfg.moderation.Run(data.Body)
+	if result.Action == moderation.Reject {
+		cfg.recordModerationDecisions(r.Context(), userID, uuid.NullUUID{UUID: chirpID, Valid: true}, data.Body, result)
+		respondWithError(w, http.StatusBadRequest, "Chirp was rejected by moderation")
+		return
+	}
+
+	revisionParams := database.CreateChirpRevisionParams{
+		CreatedAt: chirp.UpdatedAt,
+		ChirpID:   chirp.ID,
+		Body:      chirp.Body,
+	}
+	_, err = qtx.CreateChirpRevision(r.Context(), revisionParams)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to update chirp")
+		return
+	}
+
+	// Editing never clears a pending review.
+	status := moderationStatus(result)
+	if chirp.ModerationStatus == chirpPendingReview {
+		status = chirpPendingReview
+	}
+
+	updateParams := database.UpdateChirpParams{
+		ID:               chirp.ID,
+		Body:             result.Body,
+		ModerationStatus: status,
+	}
+	var mentioned []uuid.UUID
+	chirp, err = qtx.UpdateChirp(r.Context(), updateParams)
+	if err == nil {
+		mentioned, err = saveChirpEntities(r.Context(), qtx, chirp)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to update chirp")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to update chirp")
+		return
+	}
+
+	cfg.recordModerationDecisions(r.Context(), userID, uuid.NullUUID{UUID: chirp.ID, Valid: true}, data.Body, result)
+	cfg.publishNotifications(mentioned...)
+
+	resp, err := cfg.chirpResponse(r.Context(), chirp, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to update chirp")
+		return
+	}
+
+	if chirp.ModerationStatus == chirpPendingReview {
+		respondWithJson(w, http.StatusAccepted, resp)
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) getChirpRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id")
+		return
+	}
+
+	chirp, err := cfg.dbQueries.GetSingleChirp(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "no chirp found")
+		return
+	}
+
+	visible, err := cfg.canViewChirp(r.Context(), chirp, cfg.viewerID(r))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get revisions")
+		return
+	}
+
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "no chirp found")
+		return
+	}
+
+	revisions, err := cfg.dbQueries.GetChirpRevisions(r.Context(), chirpID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get revisions")
+		return
+	}
+
+	resp := []ChirpRevision{}
+	for _, revision := range revisions {
+		resp = append(resp, ChirpRevision{
+			ID:        revision.ID,
+			ChirpID:   revision.ChirpID,
+			CreatedAt: revision.CreatedAt,
+			Body:      revision.Body,
+		})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
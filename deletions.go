@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/stream"
+)
+
+const (
+	// chirpRestoreWindow is how long a deleted chirp can be restored by its
+	// author before it's purged.
+	chirpRestoreWindow = 30 * 24 * time.Hour
+	chirpPurgeBatch    = 100
+)
+
+// deleteChirp hides a chirp until it's restored or purged. The chirp is also
+// unpinned, and stays unpinned if it's restored.
+func deleteChirp(ctx context.Context, q *database.Queries, chirp database.Chirp) (stream.Event, error) {
+	_, err := q.DeleteChirp(ctx, chirp.ID)
+	if err == nil {
+		err = q.DeleteChirpPins(ctx, chirp.ID)
+	}
+	if err != nil {
+		return stream.Event{}, err
+	}
+
+	return recordChirpEvent(ctx, q, stream.ChirpDeleted, chirp)
+}
+
+// restoreChirpHandler undoes the author's deletion of a chirp within the
+// restore window. Chirps removed by a moderator can't be restored.
+func (cfg *apiConfig) restoreChirpHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to restore chirp")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	params := database.GetDeletedChirpForUpdateParams{ID: chirpID, UserID: uuid.NullUUID{UUID: userID, Valid: true}}
+	chirp, err := qtx.GetDeletedChirpForUpdate(r.Context(), params)
+	if err == nil && chirp.RemovedAt.Valid {
+		err = sql.ErrNoRows
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "no deleted chirp found")
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to restore chirp")
+		return
+	}
+
+	if time.Since(chirp.DeletedAt.Time) > chirpRestoreWindow {
+		respondWithError(w, http.StatusGone, "restore window has passed")
+		return
+	}
+
+	var event stream.Event
+	chirp, err = qtx.RestoreChirp(r.Context(), chirp.ID)
+	if err == nil {
+		event, err = recordChirpEvent(r.Context(), qtx, stream.ChirpCreated, chirp)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to restore chirp")
+		return
+	}
+
+	cfg.publishChirpEvent(event)
+
+	resp, err := cfg.chirpResponse(r.Context(), chirp, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to restore chirp")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+// purgeDeletedChirps periodically removes the chirps whose restore window has
+// passed, along with everything that references them.
+func purgeDeletedChirps(q *database.Queries, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for {
+			params := database.PurgeDeletedChirpsParams{
+				DeletedBefore: time.Now().UTC().Add(-chirpRestoreWindow),
+				BatchSize:     chirpPurgeBatch,
+			}
+			count, err := q.PurgeDeletedChirps(context.Background(), params)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				break
+			}
+
+			if count < chirpPurgeBatch {
+				break
+			}
+		}
+	}
+}
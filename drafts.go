@@ -0,0 +1,640 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/moderation"
+	"github.com/matt-horst/chirpy/internal/stream"
+	"github.com/matt-horst/chirpy/internal/timeline"
+)
+
+// Values of chirps.status.
+const (
+	chirpDraft     = "draft"
+	chirpScheduled = "scheduled"
+	chirpPublished = "published"
+)
+
+const (
+	scheduledChirpInterval = 30 * time.Second
+	scheduledChirpBatch    = 100
+	// maxScheduleAhead is how far in the future a chirp may be scheduled.
+	maxScheduleAhead = 365 * 24 * time.Hour
+)
+
+// Draft is a chirp that hasn't been published yet. Drafts without a
+// publish_at wait for their author; scheduled ones are published at
+// publish_at.
+type Draft struct {
+	Chirp
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// draftRequest is the body of both creating and replacing a draft.
+type draftRequest struct {
+	Body       string      `json:"body"`
+	MediaIDs   []uuid.UUID `json:"media_ids"`
+	Visibility string      `json:"visibility"`
+	PublishAt  *time.Time  `json:"publish_at"`
+}
+
+// validate checks the request and works out the visibility, status and
+// publish time to store.
+func (data *draftRequest) validate() (string, sql.NullTime, error) {
+	if utf8.RuneCountInString(data.Body) > maxChirpLength {
+		return "", sql.NullTime{}, errors.New("Chirp is too long")
+	}
+
+	if len(data.MediaIDs) > maxChirpMedia {
+		return "", sql.NullTime{}, errors.New("Chirp has too many media attachments")
+	}
+
+	if data.Visibility == "" {
+		data.Visibility = visibilityPublic
+	} else if !slices.Contains(chirpVisibilities, data.Visibility) {
+		return "", sql.NullTime{}, errors.New("invalid visibility")
+	}
+
+	if data.PublishAt == nil {
+		return chirpDraft, sql.NullTime{}, nil
+	}
+
+	publishAt := data.PublishAt.UTC()
+	if !publishAt.After(time.Now()) {
+		return "", sql.NullTime{}, errors.New("publish_at must be in the future")
+	}
+
+	if publishAt.After(time.Now().Add(maxScheduleAhead)) {
+		return "", sql.NullTime{}, errors.New("publish_at is too far in the future")
+	}
+
+	return chirpScheduled, sql.NullTime{Time: publishAt, Valid: true}, nil
+}
+
+func (cfg *apiConfig) draftResponse(ctx context.Context, chirp database.Chirp) (Draft, error) {
+	resp, err := cfg.chirpResponse(ctx, chirp, chirp.UserID)
+	if err != nil {
+		return Draft{}, err
+	}
+
+	mediaFiles, err := cfg.dbQueries.GetChirpMedia(ctx, chirp.ID)
+	if err != nil {
+		return Draft{}, err
+	}
+	resp.Media = mediaResponses(mediaFiles)
+
+	draft := Draft{Chirp: resp, Status: chirp.Status}
+	if chirp.PublishAt.Valid {
+		draft.PublishAt = &chirp.PublishAt.Time
+	}
+
+	return draft, nil
+}
+
+// publishedChirp holds what's left to do for a newly published chirp once
+// its transaction has committed.
+type publishedChirp struct {
+	chirp     database.Chirp
+	mentioned []uuid.UUID
+	event     stream.Event
+	streamed  bool
+}
+
+// preparePublication does the work of publishing a chirp that belongs in its
+// transaction. Pass the result to announceChirp after committing.
+func preparePublication(ctx context.Context, q *database.Queries, chirp database.Chirp, author database.User) (publishedChirp, error) {
+	mentioned, err := saveChirpEntities(ctx, q, chirp)
+	if err == nil {
+		err = timeline.Queue(ctx, q, chirp.ID)
+	}
+	if err != nil {
+		return publishedChirp{}, err
+	}
+
+	published := publishedChirp{chirp: chirp, mentioned: mentioned}
+
+	// Chirps nobody else can see yet aren't streamed.
+	published.streamed = chirp.ModerationStatus == chirpApproved && !author.ShadowbannedAt.Valid
+	if published.streamed {
+		published.event, err = recordChirpEvent(ctx, q, stream.ChirpCreated, chirp)
+		if err != nil {
+			return publishedChirp{}, err
+		}
+	}
+
+	return published, nil
+}
+
+// announceChirp streams a published chirp, notifies the users it mentions
+// and wakes the fan-out workers.
+func (cfg *apiConfig) announceChirp(published publishedChirp) {
+	if published.streamed {
+		cfg.publishChirpEvent(published.event)
+	}
+	cfg.publishNotifications(published.mentioned...)
+	cfg.fanout.Publish()
+}
+
+// publishDraft turns a draft or scheduled chirp into a published one, dated
+// now.
+func publishDraft(ctx context.Context, q *database.Queries, chirp database.Chirp, author database.User) (publishedChirp, error) {
+	chirp, err := q.PublishChirp(ctx, chirp.ID)
+	if err != nil {
+		return publishedChirp{}, err
+	}
+
+	return preparePublication(ctx, q, chirp, author)
+}
+
+// publishScheduledChirps periodically publishes the scheduled chirps that are
+// due.
+func (cfg *apiConfig) publishScheduledChirps(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for {
+			count, err := cfg.publishDueChirps(context.Background())
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				break
+			}
+
+			if count < scheduledChirpBatch {
+				break
+			}
+		}
+	}
+}
+
+// publishDueChirps publishes one batch of due chirps. The batch stays locked
+// until the transaction commits, and other instances skip locked rows, so
+// every chirp is published exactly once. Chirps by suspended authors stay
+// scheduled until the suspension ends.
+func (cfg *apiConfig) publishDueChirps(ctx context.Context) (int, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirps, err := qtx.GetDueChirps(ctx, scheduledChirpBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	published := []publishedChirp{}
+	for _, chirp := range chirps {
+		author, err := qtx.GetUserByID(ctx, chirp.UserID.UUID)
+		if err != nil {
+			return 0, err
+		}
+
+		if isSuspended(author) {
+			continue
+		}
+
+		p, err := publishDraft(ctx, qtx, chirp, author)
+		if err != nil {
+			return 0, err
+		}
+		published = append(published, p)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, p := range published {
+		cfg.announceChirp(p)
+	}
+
+	return len(chirps), nil
+}
+
+func (cfg *apiConfig) createDraftHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	data := draftRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	status, publishAt, err := data.validate()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't find user")
+		return
+	}
+
+	if isSuspended(user) {
+		respondWithError(w, http.StatusForbidden, "account is suspended")
+		return
+	}
+
+	mediaFiles, err := cfg.lookupOwnMedia(r.Context(), userID, data.MediaIDs)
+	if errors.Is(err, errInvalidMedia) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to create draft")
+		return
+	}
+
+	// Drafts are moderated when they're saved, so publishing one later
+	// can't fail.
+	result := cfg.moderation.Run(data.Body)
+	if result.Action == moderation.Reject {
+		cfg.recordModerationDecisions(r.Context(), userID, uuid.NullUUID{}, data.Body, result)
+		respondWithError(w, http.StatusBadRequest, "Chirp was rejected by moderation")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to create draft")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	params := database.CreateDraftParams{
+		Body:             result.Body,
+		UserID:           uuid.NullUUID{UUID: userID, Valid: true},
+		ModerationStatus: moderationStatus(result),
+		Visibility:       data.Visibility,
+		Status:           status,
+		PublishAt:        publishAt,
+	}
+	chirp, err := qtx.CreateDraft(r.Context(), params)
+	if err == nil {
+		err = attachChirpMedia(r.Context(), qtx, chirp.ID, mediaFiles)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to create draft")
+		return
+	}
+
+	cfg.recordModerationDecisions(r.Context(), userID, uuid.NullUUID{UUID: chirp.ID, Valid: true}, data.Body, result)
+
+	resp, err := cfg.draftResponse(r.Context(), chirp)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to create draft")
+		return
+	}
+
+	respondWithJson(w, http.StatusCreated, resp)
+}
+
+func (cfg *apiConfig) getDraftsHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetDraftsParams{
+		UserID:          uuid.NullUUID{UUID: userID, Valid: true},
+		BeforeCreatedAt: after.CreatedAt,
+		BeforeID:        after.ID,
+		PageSize:        limit,
+	}
+	chirps, err := cfg.dbQueries.GetDrafts(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get drafts")
+		return
+	}
+
+	resp := Page[Draft]{Items: []Draft{}}
+	for _, chirp := range chirps {
+		draft, err := cfg.draftResponse(r.Context(), chirp)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			respondWithError(w, http.StatusInternalServerError, "failed to get drafts")
+			return
+		}
+		resp.Items = append(resp.Items, draft)
+	}
+	if len(chirps) > 0 {
+		last := chirps[len(chirps)-1]
+		resp.NextCursor = nextCursor(len(chirps), limit, cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) getDraftHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id")
+		return
+	}
+
+	params := database.GetDraftParams{ID: chirpID, UserID: uuid.NullUUID{UUID: userID, Valid: true}}
+	chirp, err := cfg.dbQueries.GetDraft(r.Context(), params)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "no draft found")
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get draft")
+		return
+	}
+
+	resp, err := cfg.draftResponse(r.Context(), chirp)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get draft")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+// updateDraftHandler replaces a draft. Leaving out publish_at turns a
+// scheduled chirp back into a draft.
+func (cfg *apiConfig) updateDraftHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id")
+		return
+	}
+
+	data := draftRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	status, publishAt, err := data.validate()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	mediaFiles, err := cfg.lookupOwnMedia(r.Context(), userID, data.MediaIDs)
+	if errors.Is(err, errInvalidMedia) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to update draft")
+		return
+	}
+
+	result := cfg.moderation.Run(data.Body)
+	if result.Action == moderation.Reject {
+		cfg.recordModerationDecisions(r.Context(), userID, uuid.NullUUID{UUID: chirpID, Valid: true}, data.Body, result)
+		respondWithError(w, http.StatusBadRequest, "Chirp was rejected by moderation")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to update draft")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	// Locking the draft keeps the scheduler from publishing it mid-update.
+	draftParams := database.GetDraftForUpdateParams{ID: chirpID, UserID: uuid.NullUUID{UUID: userID, Valid: true}}
+	chirp, err := qtx.GetDraftForUpdate(r.Context(), draftParams)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "no draft found")
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to update draft")
+		return
+	}
+
+	params := database.UpdateDraftParams{
+		ID:               chirp.ID,
+		Body:             result.Body,
+		ModerationStatus: moderationStatus(result),
+		Visibility:       data.Visibility,
+		Status:           status,
+		PublishAt:        publishAt,
+	}
+	chirp, err = qtx.UpdateDraft(r.Context(), params)
+	if err == nil {
+		err = qtx.DeleteChirpMedia(r.Context(), chirp.ID)
+	}
+	if err == nil {
+		err = attachChirpMedia(r.Context(), qtx, chirp.ID, mediaFiles)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to update draft")
+		return
+	}
+
+	cfg.recordModerationDecisions(r.Context(), userID, uuid.NullUUID{UUID: chirp.ID, Valid: true}, data.Body, result)
+
+	resp, err := cfg.draftResponse(r.Context(), chirp)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to update draft")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) deleteDraftHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id")
+		return
+	}
+
+	params := database.DeleteDraftParams{ID: chirpID, UserID: uuid.NullUUID{UUID: userID, Valid: true}}
+	deleted, err := cfg.dbQueries.DeleteDraft(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to delete draft")
+		return
+	}
+
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "no draft found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) publishDraftHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id")
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't find user")
+		return
+	}
+
+	if isSuspended(user) {
+		respondWithError(w, http.StatusForbidden, "account is suspended")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to publish draft")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	params := database.GetDraftForUpdateParams{ID: chirpID, UserID: uuid.NullUUID{UUID: userID, Valid: true}}
+	chirp, err := qtx.GetDraftForUpdate(r.Context(), params)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "no draft found")
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to publish draft")
+		return
+	}
+
+	published, err := publishDraft(r.Context(), qtx, chirp, user)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to publish draft")
+		return
+	}
+
+	cfg.announceChirp(published)
+
+	resp, err := cfg.chirpResponse(r.Context(), published.chirp, published.chirp.UserID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to publish draft")
+		return
+	}
+
+	if published.chirp.ModerationStatus == chirpPendingReview {
+		respondWithJson(w, http.StatusAccepted, resp)
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
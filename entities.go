@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/entities"
+	"github.com/matt-horst/chirpy/internal/notifications"
+)
+
+// Chirps are limited by characters, not bytes, so emoji and accented letters
+// don't count more than once.
+const maxChirpLength = 140
+
+type Entity struct {
+	Type   string     `json:"type"`
+	Text   string     `json:"text"`
+	Value  string     `json:"value"`
+	Start  int32      `json:"start"`
+	End    int32      `json:"end"`
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+}
+
+// saveChirpEntities replaces the stored entities of a chirp with those parsed
+// from its body and notifies the users it mentions, returning the ones who
+// were notified. A mention only counts when it names an existing user who
+// hasn't blocked, and isn't blocked by, the author; anything else stays plain
+// text.
+func saveChirpEntities(ctx context.Context, q *database.Queries, chirp database.Chirp) ([]uuid.UUID, error) {
+	notified := []uuid.UUID{}
+
+	err := q.DeleteChirpEntities(ctx, chirp.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entities.Parse(chirp.Body) {
+		params := database.CreateChirpEntityParams{
+			ChirpID:     chirp.ID,
+			Kind:        string(e.Kind),
+			StartOffset: int32(e.Start),
+			EndOffset:   int32(e.End),
+			Text:        e.Text,
+			Value:       e.Value,
+		}
+
+		if e.Kind == entities.Mention {
+			user, err := q.GetUserByHandle(ctx, e.Value)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			blockedParams := database.IsBlockedBetweenParams{UserID: chirp.UserID.UUID, OtherUserID: user.ID}
+			blocked, err := q.IsBlockedBetween(ctx, blockedParams)
+			if err != nil {
+				return nil, err
+			}
+
+			if blocked {
+				continue
+			}
+
+			params.Value = user.Handle
+			params.UserID = uuid.NullUUID{UUID: user.ID, Valid: true}
+		}
+
+		err = q.CreateChirpEntity(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		// Chirps waiting on review notify no one until they're approved.
+		if e.Kind == entities.Mention && chirp.ModerationStatus == chirpApproved {
+			created, err := notify(ctx, q, params.UserID.UUID, chirp.UserID.UUID, notifications.Mention, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+			if err != nil {
+				return nil, err
+			}
+
+			if created {
+				notified = append(notified, params.UserID.UUID)
+			}
+		}
+	}
+
+	return notified, nil
+}
+
+// chirpResponses builds the JSON for a list of chirps, loading the entities
+// and polls of all of them in one query each. Poll tallies depend on whether
+// the viewer has voted.
+func (cfg *apiConfig) chirpResponses(ctx context.Context, chirps []database.Chirp, viewerID uuid.NullUUID) ([]Chirp, error) {
+	resp := []Chirp{}
+	if len(chirps) == 0 {
+		return resp, nil
+	}
+
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		ids = append(ids, chirp.ID)
+	}
+
+	rows, err := cfg.dbQueries.GetEntitiesForChirps(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byChirp := map[uuid.UUID][]Entity{}
+	for _, row := range rows {
+		e := Entity{
+			Type:  row.Kind,
+			Text:  row.Text,
+			Value: row.Value,
+			Start: row.StartOffset,
+			End:   row.EndOffset,
+		}
+		if row.UserID.Valid {
+			e.UserID = &row.UserID.UUID
+		}
+		byChirp[row.ChirpID] = append(byChirp[row.ChirpID], e)
+	}
+
+	chirpPolls, err := cfg.pollResponses(ctx, ids, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, chirp := range chirps {
+		chirpEntities := byChirp[chirp.ID]
+		if chirpEntities == nil {
+			chirpEntities = []Entity{}
+		}
+
+		resp = append(resp, Chirp{
+			ID:         chirp.ID,
+			CreatedAt:  chirp.CreatedAt,
+			UpdatedAt:  chirp.UpdatedAt,
+			Body:       chirp.Body,
+			UserID:     chirp.UserID.UUID,
+			Entities:   chirpEntities,
+			Visibility: chirp.Visibility,
+			Poll:       chirpPolls[chirp.ID],
+		})
+	}
+
+	return resp, nil
+}
+
+func (cfg *apiConfig) chirpResponse(ctx context.Context, chirp database.Chirp, viewerID uuid.NullUUID) (Chirp, error) {
+	resp, err := cfg.chirpResponses(ctx, []database.Chirp{chirp}, viewerID)
+	if err != nil {
+		return Chirp{}, err
+	}
+
+	return resp[0], nil
+}
+
+func (cfg *apiConfig) getHashtagChirpsHandler(w http.ResponseWriter, r *http.Request) {
+	tag := strings.ToLower(strings.TrimPrefix(r.PathValue("tag"), "#"))
+	if tag == "" {
+		respondWithError(w, http.StatusBadRequest, "invalid hashtag")
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetChirpsByHashtagParams{
+		Tag:             tag,
+		BeforeCreatedAt: after.CreatedAt,
+		BeforeID:        after.ID,
+		ViewerID:        cfg.viewerID(r),
+		PageSize:        limit,
+	}
+	chirps, err := cfg.dbQueries.GetChirpsByHashtag(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get chirps")
+		return
+	}
+
+	cfg.respondWithChirpPage(w, r, chirps, limit)
+}
+
+func (cfg *apiConfig) getMentionsHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetMentionsParams{
+		UserID:          userID,
+		BeforeCreatedAt: after.CreatedAt,
+		BeforeID:        after.ID,
+		PageSize:        limit,
+	}
+	chirps, err := cfg.dbQueries.GetMentions(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get mentions")
+		return
+	}
+
+	cfg.respondWithChirpPage(w, r, chirps, limit)
+}
+
+// respondWithChirpPage writes one page of a feed ordered newest first.
+func (cfg *apiConfig) respondWithChirpPage(w http.ResponseWriter, r *http.Request, chirps []database.Chirp, limit int32) {
+	items, err := cfg.chirpResponses(r.Context(), chirps, cfg.viewerID(r))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get chirps")
+		return
+	}
+
+	resp := Page[Chirp]{Items: items}
+	if len(chirps) > 0 {
+		last := chirps[len(chirps)-1]
+		resp.NextCursor = nextCursor(len(chirps), limit, cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
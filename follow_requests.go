@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+)
+
+func (cfg *apiConfig) getFollowRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetFollowRequestsParams{
+		UserID:          userID,
+		BeforeCreatedAt: after.CreatedAt,
+		BeforeID:        after.ID,
+		PageSize:        limit,
+	}
+	requests, err := cfg.dbQueries.GetFollowRequests(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get follow requests")
+		return
+	}
+
+	resp := Page[FollowEntry]{Items: []FollowEntry{}}
+	for _, request := range requests {
+		resp.Items = append(resp.Items, FollowEntry{UserID: request.UserID, FollowedAt: request.CreatedAt})
+	}
+	if len(requests) > 0 {
+		last := requests[len(requests)-1]
+		resp.NextCursor = nextCursor(len(requests), limit, cursor{CreatedAt: last.CreatedAt, ID: last.UserID})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) approveFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	requesterID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to approve follow request")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	params := database.DeleteFollowRequestParams{RequesterID: requesterID, TargetID: userID}
+	deleted, err := qtx.DeleteFollowRequest(r.Context(), params)
+	if err == nil && deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "couldn't find follow request")
+		return
+	}
+	if err == nil {
+		_, err = addFollow(r.Context(), qtx, requesterID, userID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to approve follow request")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) denyFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	requesterID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	params := database.DeleteFollowRequestParams{RequesterID: requesterID, TargetID: userID}
+	deleted, err := cfg.dbQueries.DeleteFollowRequest(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to deny follow request")
+		return
+	}
+
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "couldn't find follow request")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// approveFollowRequests turns every pending request for the user into a
+// follow.
+func approveFollowRequests(ctx context.Context, q *database.Queries, userID uuid.UUID) error {
+	requesterIDs, err := q.GetFollowRequesterIDs(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, requesterID := range requesterIDs {
+		_, err = q.DeleteFollowRequest(ctx, database.DeleteFollowRequestParams{RequesterID: requesterID, TargetID: userID})
+		if err != nil {
+			return err
+		}
+
+		_, err = addFollow(ctx, q, requesterID, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/notifications"
+	"github.com/matt-horst/chirpy/internal/timeline"
+)
+
+type FollowEntry struct {
+	UserID     uuid.UUID `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+func (cfg *apiConfig) followUserHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	if followeeID == userID {
+		respondWithError(w, http.StatusBadRequest, "can't follow yourself")
+		return
+	}
+
+	followee, err := cfg.dbQueries.GetUserByID(r.Context(), followeeID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "couldn't find user")
+		return
+	}
+
+	blocked, err := cfg.dbQueries.IsBlockedBetween(r.Context(), database.IsBlockedBetweenParams{UserID: userID, OtherUserID: followeeID})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to follow user")
+		return
+	}
+
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "can't follow this user")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to follow user")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	// Following a private account only asks to follow it, unless the
+	// request was already approved.
+	if followee.IsPrivate {
+		params := database.IsFollowingParams{FollowerID: userID, FolloweeID: followeeID}
+		following, err := qtx.IsFollowing(r.Context(), params)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			respondWithError(w, http.StatusInternalServerError, "failed to follow user")
+			return
+		}
+
+		if following {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		notified := false
+		requestParams := database.CreateFollowRequestParams{RequesterID: userID, TargetID: followeeID}
+		created, err := qtx.CreateFollowRequest(r.Context(), requestParams)
+		if err == nil && created > 0 {
+			notified, err = notify(r.Context(), qtx, followeeID, userID, notifications.FollowRequest, uuid.NullUUID{})
+		}
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			respondWithError(w, http.StatusInternalServerError, "failed to follow user")
+			return
+		}
+
+		if notified {
+			cfg.publishNotifications(followeeID)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	notified := false
+	created, err := addFollow(r.Context(), qtx, userID, followeeID)
+	if err == nil && created {
+		notified, err = notify(r.Context(), qtx, followeeID, userID, notifications.Follow, uuid.NullUUID{})
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to follow user")
+		return
+	}
+
+	if notified {
+		cfg.publishNotifications(followeeID)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// addFollow creates a follow along with everything derived from it. It
+// reports false when the follow already existed.
+func addFollow(ctx context.Context, q *database.Queries, followerID, followeeID uuid.UUID) (bool, error) {
+	params := database.CreateFollowParams{FollowerID: followerID, FolloweeID: followeeID}
+	created, err := q.CreateFollow(ctx, params)
+	if err != nil || created == 0 {
+		return false, err
+	}
+
+	err = q.IncrementFollowerCount(ctx, followeeID)
+	if err != nil {
+		return false, err
+	}
+
+	return true, timeline.Follow(ctx, q, followerID, followeeID)
+}
+
+func (cfg *apiConfig) unfollowUserHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to unfollow user")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	err = removeFollow(r.Context(), qtx, userID, followeeID)
+	if err == nil {
+		// Unfollowing also withdraws a pending follow request.
+		_, err = qtx.DeleteFollowRequest(r.Context(), database.DeleteFollowRequestParams{RequesterID: userID, TargetID: followeeID})
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to unfollow user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeFollow deletes a follow along with everything that was derived from it.
+func removeFollow(ctx context.Context, q *database.Queries, followerID, followeeID uuid.UUID) error {
+	params := database.DeleteFollowParams{FollowerID: followerID, FolloweeID: followeeID}
+	deleted, err := q.DeleteFollow(ctx, params)
+	if err != nil || deleted == 0 {
+		return err
+	}
+
+	followerCount, err := q.DecrementFollowerCount(ctx, followeeID)
+	if err != nil {
+		return err
+	}
+
+	return timeline.Unfollow(ctx, q, followerID, followeeID, followerCount)
+}
+
+func (cfg *apiConfig) getFollowersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetFollowersParams{
+		UserID:          userID,
+		BeforeCreatedAt: after.CreatedAt,
+		BeforeID:        after.ID,
+		PageSize:        limit,
+	}
+	followers, err := cfg.dbQueries.GetFollowers(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get followers")
+		return
+	}
+
+	resp := Page[FollowEntry]{Items: []FollowEntry{}}
+	for _, follower := range followers {
+		resp.Items = append(resp.Items, FollowEntry{UserID: follower.UserID, FollowedAt: follower.CreatedAt})
+	}
+	if len(followers) > 0 {
+		last := followers[len(followers)-1]
+		resp.NextCursor = nextCursor(len(followers), limit, cursor{CreatedAt: last.CreatedAt, ID: last.UserID})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) getFollowingHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetFollowingParams{
+		UserID:          userID,
+		BeforeCreatedAt: after.CreatedAt,
+		BeforeID:        after.ID,
+		PageSize:        limit,
+	}
+	following, err := cfg.dbQueries.GetFollowing(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get following")
+		return
+	}
+
+	resp := Page[FollowEntry]{Items: []FollowEntry{}}
+	for _, followee := range following {
+		resp.Items = append(resp.Items, FollowEntry{UserID: followee.UserID, FollowedAt: followee.CreatedAt})
+	}
+	if len(following) > 0 {
+		last := following[len(following)-1]
+		resp.NextCursor = nextCursor(len(following), limit, cursor{CreatedAt: last.CreatedAt, ID: last.UserID})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) getTimelineHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetHomeTimelineParams{
+		UserID:             userID,
+		BeforeCreatedAt:    after.CreatedAt,
+		BeforeID:           after.ID,
+		PageSize:           limit,
+		HeavyFollowerCount: timeline.HeavyFollowerCount,
+	}
+	chirps, err := cfg.dbQueries.GetHomeTimeline(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get timeline")
+		return
+	}
+
+	cfg.respondWithChirpPage(w, r, chirps, limit)
+}
+
+func (cfg *apiConfig) rebuildTimelineHandler(w http.ResponseWriter, r *http.Request) {
+	_, ok := cfg.authenticateModerator(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	_, err = cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "couldn't find user")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to rebuild timeline")
+		return
+	}
+	defer tx.Rollback()
+
+	entries, err := timeline.Rebuild(r.Context(), cfg.dbQueries.WithTx(tx), userID)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to rebuild timeline")
+		return
+	}
+
+	resp := struct {
+		Entries int64 `json:"entries"`
+	}{Entries: entries}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
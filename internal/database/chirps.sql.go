@@ -7,25 +7,44 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+const canViewChirp = `-- name: CanViewChirp :one
+SELECT can_view_chirp($1::UUID, $2::UUID) AS visible
+`
+
+type CanViewChirpParams struct {
+	ChirpID  uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) CanViewChirp(ctx context.Context, arg CanViewChirpParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, canViewChirp, arg.ChirpID, arg.ViewerID)
+	var visible bool
+	err := row.Scan(&visible)
+	return visible, err
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, moderation_status, visibility)
 VALUES (
-    gen_random_uuid(), NOW(), NOW(), $1, $2
+    gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, moderation_status, visibility, status, publish_at, deleted_at, removed_at, removed_by
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.NullUUID
+	Body             string
+	UserID           uuid.NullUUID
+	ModerationStatus string
+	Visibility       string
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID, arg.ModerationStatus, arg.Visibility)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -33,14 +52,21 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.ModerationStatus,
+		&i.Visibility,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.RemovedAt,
+		&i.RemovedBy,
 	)
 	return i, err
 }
 
 const deleteChirp = `-- name: DeleteChirp :one
-DELETE FROM chirps
+UPDATE chirps SET deleted_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, moderation_status, visibility, status, publish_at, deleted_at, removed_at, removed_by
 `
 
 func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -52,16 +78,30 @@ func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.ModerationStatus,
+		&i.Visibility,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.RemovedAt,
+		&i.RemovedBy,
 	)
 	return i, err
 }
 
 const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps ORDER BY created_at
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.moderation_status, chirps.visibility, chirps.status, chirps.publish_at, chirps.deleted_at, chirps.removed_at, chirps.removed_by FROM chirps
+WHERE chirps.visibility <> 'unlisted'
+    AND can_view_chirp(chirps.id, $1)
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = $1 AND mutes.muted_id = chirps.user_id
+    )
+ORDER BY chirps.created_at
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
+func (q *Queries) GetAllChirps(ctx context.Context, viewerID uuid.NullUUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirps, viewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -75,6 +115,13 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.ModerationStatus,
+			&i.Visibility,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+			&i.RemovedAt,
+			&i.RemovedBy,
 		); err != nil {
 			return nil, err
 		}
@@ -90,11 +137,19 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getAllChirpsByAuthor = `-- name: GetAllChirpsByAuthor :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE user_id = $1 ORDER BY created_at
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.moderation_status, chirps.visibility, chirps.status, chirps.publish_at, chirps.deleted_at, chirps.removed_at, chirps.removed_by FROM chirps
+WHERE chirps.user_id = $1
+    AND can_view_chirp(chirps.id, $2)
+ORDER BY chirps.created_at
 `
 
-func (q *Queries) GetAllChirpsByAuthor(ctx context.Context, userID uuid.NullUUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirpsByAuthor, userID)
+type GetAllChirpsByAuthorParams struct {
+	AuthorID uuid.NullUUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetAllChirpsByAuthor(ctx context.Context, arg GetAllChirpsByAuthorParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirpsByAuthor, arg.AuthorID, arg.ViewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -108,6 +163,13 @@ func (q *Queries) GetAllChirpsByAuthor(ctx context.Context, userID uuid.NullUUID
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.ModerationStatus,
+			&i.Visibility,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+			&i.RemovedAt,
+			&i.RemovedBy,
 		); err != nil {
 			return nil, err
 		}
@@ -122,8 +184,40 @@ func (q *Queries) GetAllChirpsByAuthor(ctx context.Context, userID uuid.NullUUID
 	return items, nil
 }
 
+const getDeletedChirpForUpdate = `-- name: GetDeletedChirpForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, moderation_status, visibility, status, publish_at, deleted_at, removed_at, removed_by FROM chirps
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+FOR UPDATE
+`
+
+type GetDeletedChirpForUpdateParams struct {
+	ID     uuid.UUID
+	UserID uuid.NullUUID
+}
+
+func (q *Queries) GetDeletedChirpForUpdate(ctx context.Context, arg GetDeletedChirpForUpdateParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getDeletedChirpForUpdate, arg.ID, arg.UserID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ModerationStatus,
+		&i.Visibility,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.RemovedAt,
+		&i.RemovedBy,
+	)
+	return i, err
+}
+
 const getSingleChirp = `-- name: GetSingleChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE id = $1
+SELECT id, created_at, updated_at, body, user_id, moderation_status, visibility, status, publish_at, deleted_at, removed_at, removed_by FROM chirps
+WHERE id = $1 AND deleted_at IS NULL AND removed_at IS NULL
 `
 
 func (q *Queries) GetSingleChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -135,6 +229,231 @@ func (q *Queries) GetSingleChirp(ctx context.Context, id uuid.UUID) (Chirp, erro
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.ModerationStatus,
+		&i.Visibility,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.RemovedAt,
+		&i.RemovedBy,
+	)
+	return i, err
+}
+
+const getSingleChirpForUpdate = `-- name: GetSingleChirpForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, moderation_status, visibility, status, publish_at, deleted_at, removed_at, removed_by FROM chirps
+WHERE id = $1 AND deleted_at IS NULL AND removed_at IS NULL
+FOR UPDATE
+`
+
+func (q *Queries) GetSingleChirpForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getSingleChirpForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ModerationStatus,
+		&i.Visibility,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.RemovedAt,
+		&i.RemovedBy,
+	)
+	return i, err
+}
+
+const inChirpAudience = `-- name: InChirpAudience :one
+SELECT chirp_audience_includes($1::UUID, $2::UUID) AS included
+`
+
+type InChirpAudienceParams struct {
+	ChirpID  uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) InChirpAudience(ctx context.Context, arg InChirpAudienceParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, inChirpAudience, arg.ChirpID, arg.ViewerID)
+	var included bool
+	err := row.Scan(&included)
+	return included, err
+}
+
+const purgeDeletedChirps = `-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE id IN (
+    SELECT id FROM chirps
+    WHERE deleted_at < $1
+    LIMIT $2
+)
+`
+
+type PurgeDeletedChirpsParams struct {
+	DeletedBefore time.Time
+	BatchSize     int32
+}
+
+func (q *Queries) PurgeDeletedChirps(ctx context.Context, arg PurgeDeletedChirpsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedChirps, arg.DeletedBefore, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const removeChirp = `-- name: RemoveChirp :one
+UPDATE chirps SET removed_at = NOW(), removed_by = $2
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, moderation_status, visibility, status, publish_at, deleted_at, removed_at, removed_by
+`
+
+type RemoveChirpParams struct {
+	ID        uuid.UUID
+	RemovedBy uuid.NullUUID
+}
+
+func (q *Queries) RemoveChirp(ctx context.Context, arg RemoveChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, removeChirp, arg.ID, arg.RemovedBy)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ModerationStatus,
+		&i.Visibility,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.RemovedAt,
+		&i.RemovedBy,
+	)
+	return i, err
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps SET deleted_at = NULL
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, moderation_status, visibility, status, publish_at, deleted_at, removed_at, removed_by
+`
+
+func (q *Queries) RestoreChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ModerationStatus,
+		&i.Visibility,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.RemovedAt,
+		&i.RemovedBy,
+	)
+	return i, err
+}
+
+const setChirpModerationStatus = `-- name: SetChirpModerationStatus :one
+UPDATE chirps
+SET updated_at = NOW(), moderation_status = $2
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, moderation_status, visibility, status, publish_at, deleted_at, removed_at, removed_by
+`
+
+type SetChirpModerationStatusParams struct {
+	ID               uuid.UUID
+	ModerationStatus string
+}
+
+func (q *Queries) SetChirpModerationStatus(ctx context.Context, arg SetChirpModerationStatusParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, setChirpModerationStatus, arg.ID, arg.ModerationStatus)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ModerationStatus,
+		&i.Visibility,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.RemovedAt,
+		&i.RemovedBy,
+	)
+	return i, err
+}
+
+const setChirpVisibility = `-- name: SetChirpVisibility :one
+UPDATE chirps
+SET updated_at = NOW(), visibility = $2
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, moderation_status, visibility, status, publish_at, deleted_at, removed_at, removed_by
+`
+
+type SetChirpVisibilityParams struct {
+	ID         uuid.UUID
+	Visibility string
+}
+
+func (q *Queries) SetChirpVisibility(ctx context.Context, arg SetChirpVisibilityParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, setChirpVisibility, arg.ID, arg.Visibility)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ModerationStatus,
+		&i.Visibility,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.RemovedAt,
+		&i.RemovedBy,
+	)
+	return i, err
+}
+
+const updateChirp = `-- name: UpdateChirp :one
+UPDATE chirps
+SET updated_at = NOW(), body = $2, moderation_status = $3
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, moderation_status, visibility, status, publish_at, deleted_at, removed_at, removed_by
+`
+
+type UpdateChirpParams struct {
+	ID               uuid.UUID
+	Body             string
+	ModerationStatus string
+}
+
+func (q *Queries) UpdateChirp(ctx context.Context, arg UpdateChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirp, arg.ID, arg.Body, arg.ModerationStatus)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ModerationStatus,
+		&i.Visibility,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.RemovedAt,
+		&i.RemovedBy,
 	)
 	return i, err
 }
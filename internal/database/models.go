@@ -11,12 +11,255 @@ import (
 	"github.com/google/uuid"
 )
 
+type Appeal struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	SuspendedAt time.Time
+	Message     string
+	Status      string
+	ReviewerID  uuid.NullUUID
+}
+
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Body             string
+	UserID           uuid.NullUUID
+	ModerationStatus string
+	Visibility       string
+	Status           string
+	PublishAt        sql.NullTime
+	DeletedAt        sql.NullTime
+	RemovedAt        sql.NullTime
+	RemovedBy        uuid.NullUUID
+}
+
+type ChirpEntity struct {
+	ChirpID     uuid.UUID
+	Kind        string
+	StartOffset int32
+	EndOffset   int32
+	Text        string
+	Value       string
+	UserID      uuid.NullUUID
+}
+
+type ChirpEvent struct {
+	ID        int64
+	CreatedAt time.Time
+	Kind      string
+	ChirpID   uuid.UUID
+	AuthorID  uuid.UUID
+	Hashtags  []string
+	Unlisted  bool
+}
+
+type ChirpMedium struct {
+	ChirpID  uuid.UUID
+	MediaID  uuid.UUID
+	Position int32
+}
+
+type ChirpReport struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	ChirpID    uuid.NullUUID
+	ReporterID uuid.UUID
+	Reason     string
+	Details    string
+	Status     string
+}
+
+type ChirpRevision struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	ChirpID   uuid.UUID
+	Body      string
+}
+
+type Conversation struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	PairKey   sql.NullString
+}
+
+type ConversationMember struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadAt     sql.NullTime
+}
+
+type FanoutJob struct {
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+	RunAt     time.Time
+	Attempts  int32
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type FollowRequest struct {
+	RequesterID uuid.UUID
+	TargetID    uuid.UUID
+	CreatedAt   time.Time
+}
+
+type HandleRedirect struct {
+	OldHandle string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type List struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	OwnerID     uuid.UUID
+	Name        string
+	Description string
+	IsPrivate   bool
+}
+
+type ListMember struct {
+	ListID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type MediaFile struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UserID       uuid.UUID
+	ContentType  string
+	Width        int32
+	Height       int32
+	SizeBytes    int32
+	BlobKey      string
+	ThumbnailKey string
+}
+
+type Message struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+}
+
+type ModerationAction struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	ModeratorID  uuid.UUID
+	ChirpID      uuid.NullUUID
+	TargetUserID uuid.NullUUID
+	Action       string
+	Note         string
+}
+
+type ModerationDecision struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	ChirpID   uuid.NullUUID
 	Body      string
-	UserID    uuid.NullUUID
+	Filter    string
+	Action    string
+	Reason    string
+	Matches   []string
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	ActorID   uuid.UUID
+	Kind      string
+	ChirpID   uuid.NullUUID
+	GroupKey  string
+	ReadAt    sql.NullTime
+}
+
+type NotificationPreference struct {
+	UserID  uuid.UUID
+	Kind    string
+	Enabled bool
+}
+
+type PinnedChirp struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	Position  int32
+	CreatedAt time.Time
+}
+
+type PolkaEvent struct {
+	ID          uuid.UUID
+	EventID     string
+	ReceivedAt  time.Time
+	UpdatedAt   time.Time
+	Event       string
+	Payload     []byte
+	Status      string
+	Attempts    int32
+	LastError   string
+	ProcessedAt sql.NullTime
+}
+
+type Poll struct {
+	ChirpID        uuid.UUID
+	CreatedAt      time.Time
+	ClosesAt       time.Time
+	MultipleChoice bool
+	VoterCount     int32
+}
+
+type PollOption struct {
+	ChirpID   uuid.UUID
+	Position  int32
+	Text      string
+	VoteCount int32
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	Positions []int32
+}
+
+type RateLimitBucket struct {
+	Key       string
+	Tokens    float64
+	UpdatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,11 +271,55 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type Subscription struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	Plan        string
+	Status      string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+}
+
+type TimelineEntry struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	AuthorID  uuid.UUID
+	CreatedAt time.Time
+}
+
+type TrendDenylist struct {
+	Tag       string
+	CreatedAt time.Time
+	CreatedBy uuid.NullUUID
+}
+
 type User struct {
-	ID             uuid.UUID
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsChirpyRed    bool
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Email            string
+	HashedPassword   string
+	IsChirpyRed      bool
+	IsModerator      bool
+	SuspendedAt      sql.NullTime
+	SuspendedUntil   sql.NullTime
+	SuspensionReason string
+	ShadowbannedAt   sql.NullTime
+	FollowerCount    int32
+	Handle           string
+	DisplayName      string
+	Bio              string
+	AvatarUrl        string
+	Locale           string
+	DmPolicy         string
+	IsPrivate        bool
+}
+
+type WsTicket struct {
+	Ticket    string
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	ExpiresAt time.Time
 }
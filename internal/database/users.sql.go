@@ -7,25 +7,27 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
-    gen_random_uuid(), NOW(), NOW(), $1, $2
+    gen_random_uuid(), NOW(), NOW(), $1, $2, $3
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_moderator, suspended_at, suspended_until, suspension_reason, shadowbanned_at, follower_count, handle, display_name, bio, avatar_url, locale, dm_policy, is_private
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         string
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.Handle)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -34,12 +36,39 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.ShadowbannedAt,
+		&i.FollowerCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Locale,
+		&i.DmPolicy,
+		&i.IsPrivate,
 	)
 	return i, err
 }
 
+const decrementFollowerCount = `-- name: DecrementFollowerCount :one
+UPDATE users
+SET follower_count = follower_count - 1
+WHERE id = $1
+RETURNING follower_count
+`
+
+func (q *Queries) DecrementFollowerCount(ctx context.Context, id uuid.UUID) (int32, error) {
+	row := q.db.QueryRowContext(ctx, decrementFollowerCount, id)
+	var follower_count int32
+	err := row.Scan(&follower_count)
+	return follower_count, err
+}
+
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users WHERE email = $1
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_moderator, suspended_at, suspended_until, suspension_reason, shadowbanned_at, follower_count, handle, display_name, bio, avatar_url, locale, dm_policy, is_private FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUser(ctx context.Context, email string) (User, error) {
@@ -52,6 +81,234 @@ func (q *Queries) GetUser(ctx context.Context, email string) (User, error) {
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.ShadowbannedAt,
+		&i.FollowerCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Locale,
+		&i.DmPolicy,
+		&i.IsPrivate,
+	)
+	return i, err
+}
+
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_moderator, suspended_at, suspended_until, suspension_reason, shadowbanned_at, follower_count, handle, display_name, bio, avatar_url, locale, dm_policy, is_private FROM users WHERE lower(handle) = lower($1)
+`
+
+func (q *Queries) GetUserByHandle(ctx context.Context, handle string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.ShadowbannedAt,
+		&i.FollowerCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Locale,
+		&i.DmPolicy,
+		&i.IsPrivate,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_moderator, suspended_at, suspended_until, suspension_reason, shadowbanned_at, follower_count, handle, display_name, bio, avatar_url, locale, dm_policy, is_private FROM users WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.ShadowbannedAt,
+		&i.FollowerCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Locale,
+		&i.DmPolicy,
+		&i.IsPrivate,
+	)
+	return i, err
+}
+
+const incrementFollowerCount = `-- name: IncrementFollowerCount :exec
+UPDATE users
+SET follower_count = follower_count + 1
+WHERE id = $1
+`
+
+func (q *Queries) IncrementFollowerCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, incrementFollowerCount, id)
+	return err
+}
+
+const liftUserShadowban = `-- name: LiftUserShadowban :one
+UPDATE users
+SET updated_at = NOW(), shadowbanned_at = NULL
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_moderator, suspended_at, suspended_until, suspension_reason, shadowbanned_at, follower_count, handle, display_name, bio, avatar_url, locale, dm_policy, is_private
+`
+
+func (q *Queries) LiftUserShadowban(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, liftUserShadowban, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.ShadowbannedAt,
+		&i.FollowerCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Locale,
+		&i.DmPolicy,
+		&i.IsPrivate,
+	)
+	return i, err
+}
+
+const liftUserSuspension = `-- name: LiftUserSuspension :one
+UPDATE users
+SET updated_at = NOW(), suspended_at = NULL, suspended_until = NULL, suspension_reason = ''
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_moderator, suspended_at, suspended_until, suspension_reason, shadowbanned_at, follower_count, handle, display_name, bio, avatar_url, locale, dm_policy, is_private
+`
+
+func (q *Queries) LiftUserSuspension(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, liftUserSuspension, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.ShadowbannedAt,
+		&i.FollowerCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Locale,
+		&i.DmPolicy,
+		&i.IsPrivate,
+	)
+	return i, err
+}
+
+const shadowbanUser = `-- name: ShadowbanUser :one
+UPDATE users
+SET updated_at = NOW(), shadowbanned_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_moderator, suspended_at, suspended_until, suspension_reason, shadowbanned_at, follower_count, handle, display_name, bio, avatar_url, locale, dm_policy, is_private
+`
+
+func (q *Queries) ShadowbanUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, shadowbanUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.ShadowbannedAt,
+		&i.FollowerCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Locale,
+		&i.DmPolicy,
+		&i.IsPrivate,
+	)
+	return i, err
+}
+
+const suspendUser = `-- name: SuspendUser :one
+UPDATE users
+SET updated_at = NOW(), suspended_at = NOW(), suspended_until = $2, suspension_reason = $3
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_moderator, suspended_at, suspended_until, suspension_reason, shadowbanned_at, follower_count, handle, display_name, bio, avatar_url, locale, dm_policy, is_private
+`
+
+type SuspendUserParams struct {
+	ID               uuid.UUID
+	SuspendedUntil   sql.NullTime
+	SuspensionReason string
+}
+
+func (q *Queries) SuspendUser(ctx context.Context, arg SuspendUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, suspendUser, arg.ID, arg.SuspendedUntil, arg.SuspensionReason)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.ShadowbannedAt,
+		&i.FollowerCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Locale,
+		&i.DmPolicy,
+		&i.IsPrivate,
 	)
 	return i, err
 }
@@ -60,7 +317,7 @@ const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET updated_at = NOW(), email = $2, hashed_password = $3
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_moderator, suspended_at, suspended_until, suspension_reason, shadowbanned_at, follower_count, handle, display_name, bio, avatar_url, locale, dm_policy, is_private
 `
 
 type UpdateUserParams struct {
@@ -79,19 +336,43 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.ShadowbannedAt,
+		&i.FollowerCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Locale,
+		&i.DmPolicy,
+		&i.IsPrivate,
 	)
 	return i, err
 }
 
-const upgradeUserToChirpyRed = `-- name: UpgradeUserToChirpyRed :one
+const updateUserProfile = `-- name: UpdateUserProfile :one
 UPDATE users
-SET updated_at = NOW(), is_chirpy_red = true
+SET updated_at = NOW(), handle = $2, display_name = $3, bio = $4, avatar_url = $5, locale = $6, dm_policy = $7, is_private = $8
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_moderator, suspended_at, suspended_until, suspension_reason, shadowbanned_at, follower_count, handle, display_name, bio, avatar_url, locale, dm_policy, is_private
 `
 
-func (q *Queries) UpgradeUserToChirpyRed(ctx context.Context, id uuid.UUID) (User, error) {
-	row := q.db.QueryRowContext(ctx, upgradeUserToChirpyRed, id)
+type UpdateUserProfileParams struct {
+	ID          uuid.UUID
+	Handle      string
+	DisplayName string
+	Bio         string
+	AvatarUrl   string
+	Locale      string
+	DmPolicy    string
+	IsPrivate   bool
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile, arg.ID, arg.Handle, arg.DisplayName, arg.Bio, arg.AvatarUrl, arg.Locale, arg.DmPolicy, arg.IsPrivate)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -100,6 +381,19 @@ func (q *Queries) UpgradeUserToChirpyRed(ctx context.Context, id uuid.UUID) (Use
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.ShadowbannedAt,
+		&i.FollowerCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Locale,
+		&i.DmPolicy,
+		&i.IsPrivate,
 	)
 	return i, err
 }
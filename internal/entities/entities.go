@@ -0,0 +1,148 @@
+// Package entities finds mentions, hashtags and links in chirp bodies.
+package entities
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+type Kind string
+
+const (
+	Mention Kind = "mention"
+	Hashtag Kind = "hashtag"
+	URL     Kind = "url"
+)
+
+const (
+	maxMentionLength = 15
+	maxHashtagLength = 100
+)
+
+// Entity is a span of a chirp body. Start and End are offsets in runes, with
+// End exclusive, so clients can slice the body the same way they count its
+// length.
+type Entity struct {
+	Kind Kind
+	// Text is the span as written, including any leading '@' or '#'.
+	Text string
+	// Value is the handle of a mention, the lowercased tag of a hashtag or
+	// the address of a URL.
+	Value string
+	Start int
+	End   int
+}
+
+var urlSchemes = []string{"https://", "http://"}
+
+// Characters that usually end the sentence around a link rather than belong
+// to it.
+const urlTrailingPunctuation = `.,:;!?'")]}`
+
+// Parse returns the entities in body ordered by their position. Mentions and
+// hashtags inside links aren't reported separately.
+func Parse(body string) []Entity {
+	runes := []rune(body)
+	entities := []Entity{}
+
+	for i := 0; i < len(runes); i++ {
+		if i > 0 && isWordRune(runes[i-1]) {
+			continue
+		}
+
+		if end, ok := scanURL(runes, i); ok {
+			entities = append(entities, entity(runes, URL, i, end, string(runes[i:end])))
+			i = end - 1
+			continue
+		}
+
+		switch runes[i] {
+		case '@':
+			end := i + 1
+			for end < len(runes) && isHandleRune(runes[end]) {
+				end++
+			}
+
+			length := end - i - 1
+			if length > 0 && length <= maxMentionLength && !continuesWord(runes, end) {
+				entities = append(entities, entity(runes, Mention, i, end, string(runes[i+1:end])))
+				i = end - 1
+			}
+		case '#':
+			end := i + 1
+			hasLetter := false
+			for end < len(runes) && isWordRune(runes[end]) {
+				hasLetter = hasLetter || unicode.IsLetter(runes[end])
+				end++
+			}
+
+			length := end - i - 1
+			if hasLetter && length <= maxHashtagLength && !continuesWord(runes, end) {
+				entities = append(entities, entity(runes, Hashtag, i, end, strings.ToLower(string(runes[i+1:end]))))
+				i = end - 1
+			}
+		}
+	}
+
+	return entities
+}
+
+// Values returns the distinct values of the entities of the given kind.
+func Values(entities []Entity, kind Kind) []string {
+	values := []string{}
+	for _, e := range entities {
+		if e.Kind == kind && !slices.Contains(values, e.Value) {
+			values = append(values, e.Value)
+		}
+	}
+	return values
+}
+
+func entity(runes []rune, kind Kind, start, end int, value string) Entity {
+	return Entity{
+		Kind:  kind,
+		Text:  string(runes[start:end]),
+		Value: value,
+		Start: start,
+		End:   end,
+	}
+}
+
+// scanURL reports where a link starting at i ends, if one does.
+func scanURL(runes []rune, i int) (int, bool) {
+	rest := string(runes[i:min(len(runes), i+len("https://"))])
+
+	for _, scheme := range urlSchemes {
+		if !strings.HasPrefix(strings.ToLower(rest), scheme) {
+			continue
+		}
+
+		start := i + len(scheme)
+		end := start
+		for end < len(runes) && !unicode.IsSpace(runes[end]) {
+			end++
+		}
+		for end > start && strings.ContainsRune(urlTrailingPunctuation, runes[end-1]) {
+			end--
+		}
+
+		return end, end > start
+	}
+
+	return 0, false
+}
+
+// continuesWord rejects spans like "@alice@example.com" or "#tag#tag" that
+// run straight into another marker.
+func continuesWord(runes []rune, end int) bool {
+	return end < len(runes) && (runes[end] == '@' || runes[end] == '#')
+}
+
+func isHandleRune(r rune) bool {
+	return r == '_' || (r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)))
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
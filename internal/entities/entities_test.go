@@ -0,0 +1,114 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name     string
+		body     string
+		expected []Entity
+	}{
+		{
+			name:     "Plain text",
+			body:     "just setting up my chirpy",
+			expected: []Entity{},
+		},
+		{
+			name: "Mention",
+			body: "hi @alice!",
+			expected: []Entity{
+				{Kind: Mention, Text: "@alice", Value: "alice", Start: 3, End: 9},
+			},
+		},
+		{
+			name: "Hashtag is lowercased",
+			body: "#GoLang rocks",
+			expected: []Entity{
+				{Kind: Hashtag, Text: "#GoLang", Value: "golang", Start: 0, End: 7},
+			},
+		},
+		{
+			name: "Offsets count runes",
+			body: "héllo wörld #ünïcode @bob",
+			expected: []Entity{
+				{Kind: Hashtag, Text: "#ünïcode", Value: "ünïcode", Start: 12, End: 20},
+				{Kind: Mention, Text: "@bob", Value: "bob", Start: 21, End: 25},
+			},
+		},
+		{
+			name: "URL with trailing punctuation",
+			body: "read https://example.com/a?b=c#frag.",
+			expected: []Entity{
+				{Kind: URL, Text: "https://example.com/a?b=c#frag", Value: "https://example.com/a?b=c#frag", Start: 5, End: 35},
+			},
+		},
+		{
+			name: "Mentions inside URLs are ignored",
+			body: "http://example.com/@alice (see @bob)",
+			expected: []Entity{
+				{Kind: URL, Text: "http://example.com/@alice", Value: "http://example.com/@alice", Start: 0, End: 25},
+				{Kind: Mention, Text: "@bob", Value: "bob", Start: 31, End: 35},
+			},
+		},
+		{
+			name:     "Email addresses are not mentions",
+			body:     "mail me at alice@example.com",
+			expected: []Entity{},
+		},
+		{
+			name:     "Hashtags need a letter",
+			body:     "we're #1",
+			expected: []Entity{},
+		},
+		{
+			name:     "Mid-word markers",
+			body:     "abc#def ghi@jkl @mno#pqr",
+			expected: []Entity{},
+		},
+		{
+			name:     "Mention too long",
+			body:     "@abcdefghijklmnop",
+			expected: []Entity{},
+		},
+		{
+			name:     "Bare scheme",
+			body:     "https:// nothing here",
+			expected: []Entity{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := Parse(c.body)
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("expected %+v, got %+v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValues(t *testing.T) {
+	entities := Parse("@alice #go @bob #Go @alice")
+
+	cases := []struct {
+		name     string
+		kind     Kind
+		expected []string
+	}{
+		{name: "Mentions", kind: Mention, expected: []string{"alice", "bob"}},
+		{name: "Hashtags", kind: Hashtag, expected: []string{"go"}},
+		{name: "URLs", kind: URL, expected: []string{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := Values(entities, c.kind)
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("expected %v, got %v", c.expected, actual)
+			}
+		})
+	}
+}
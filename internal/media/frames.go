@@ -0,0 +1,79 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errTruncatedGIF = errors.New("truncated gif")
+
+// gifFrames counts the frames of a GIF and the pixels they cover without
+// decoding them, by walking the block structure.
+func gifFrames(data []byte) (int, int, error) {
+	// Header and logical screen descriptor.
+	i := 13
+	if len(data) < i {
+		return 0, 0, errTruncatedGIF
+	}
+	if data[10]&0x80 != 0 {
+		i += 3 << (data[10]&0x07 + 1)
+	}
+
+	frames, pixels := 0, 0
+	for i < len(data) {
+		switch data[i] {
+		case 0x21:
+			// Extension: a label, then data sub-blocks.
+			var err error
+			i, err = skipSubBlocks(data, i+2)
+			if err != nil {
+				return 0, 0, err
+			}
+		case 0x2c:
+			if i+10 > len(data) {
+				return 0, 0, errTruncatedGIF
+			}
+
+			width := int(binary.LittleEndian.Uint16(data[i+5:]))
+			height := int(binary.LittleEndian.Uint16(data[i+7:]))
+			frames++
+			pixels += width * height
+
+			packed := data[i+9]
+			i += 10
+			if packed&0x80 != 0 {
+				i += 3 << (packed&0x07 + 1)
+			}
+
+			// LZW minimum code size, then the image data.
+			var err error
+			i, err = skipSubBlocks(data, i+1)
+			if err != nil {
+				return 0, 0, err
+			}
+		case 0x3b:
+			return frames, pixels, nil
+		default:
+			return 0, 0, errTruncatedGIF
+		}
+	}
+
+	return frames, pixels, nil
+}
+
+// skipSubBlocks returns the index just past the block terminator of the data
+// sub-blocks starting at i.
+func skipSubBlocks(data []byte, i int) (int, error) {
+	for {
+		if i >= len(data) {
+			return 0, errTruncatedGIF
+		}
+
+		size := int(data[i])
+		i++
+		if size == 0 {
+			return i, nil
+		}
+		i += size
+	}
+}
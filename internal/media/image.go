@@ -0,0 +1,209 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+const (
+	// MaxPixels bounds the decoded size of an upload so a small, highly
+	// compressed file can't exhaust memory.
+	MaxPixels = 25_000_000
+
+	// MaxFrames and MaxGIFPixels bound an animated GIF, whose frames are all
+	// decoded at once. MaxGIFPixels counts every frame.
+	MaxFrames    = 500
+	MaxGIFPixels = 100_000_000
+
+	// ThumbnailSize is the longest side of a generated thumbnail.
+	ThumbnailSize = 400
+
+	jpegQuality = 85
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooLarge        = errors.New("image dimensions are too large")
+)
+
+// Extensions maps the content types Process accepts to the file extensions
+// their blobs are stored under.
+var Extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type Image struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Process decodes an uploaded image and encodes it again, which drops EXIF
+// and any other metadata the original carried. JPEGs are rotated upright
+// first since their EXIF orientation is lost. It also returns a JPEG
+// thumbnail no larger than ThumbnailSize on either side.
+func Process(data []byte) (Image, Image, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, Image{}, ErrUnsupportedType
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return Image{}, Image{}, ErrTooLarge
+	}
+
+	var img image.Image
+	var buf bytes.Buffer
+	var contentType string
+
+	switch format {
+	case "jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, Image{}, err
+		}
+
+		img = orient(img, jpegOrientation(data))
+		contentType = "image/jpeg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	case "png":
+		img, err = png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, Image{}, err
+		}
+
+		contentType = "image/png"
+		err = png.Encode(&buf, img)
+	case "gif":
+		// Keep every frame so animations survive.
+		var frames, pixels int
+		frames, pixels, err = gifFrames(data)
+		if err != nil {
+			return Image{}, Image{}, ErrUnsupportedType
+		}
+
+		if frames > MaxFrames || pixels > MaxGIFPixels {
+			return Image{}, Image{}, ErrTooLarge
+		}
+
+		var g *gif.GIF
+		g, err = gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, Image{}, err
+		}
+
+		canvas := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+		draw.Draw(canvas, g.Image[0].Bounds(), g.Image[0], g.Image[0].Bounds().Min, draw.Over)
+		img = canvas
+		contentType = "image/gif"
+		err = gif.EncodeAll(&buf, g)
+	default:
+		return Image{}, Image{}, ErrUnsupportedType
+	}
+	if err != nil {
+		return Image{}, Image{}, err
+	}
+
+	original := Image{
+		Data:        buf.Bytes(),
+		ContentType: contentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+	}
+
+	thumbnail, err := Thumbnail(img, ThumbnailSize)
+	if err != nil {
+		return Image{}, Image{}, err
+	}
+
+	return original, thumbnail, nil
+}
+
+// Thumbnail scales img down to fit within size by size and encodes it as a
+// JPEG. Transparent areas become white.
+func Thumbnail(img image.Image, size int) (Image, error) {
+	width, height := Fit(img.Bounds().Dx(), img.Bounds().Dy(), size)
+	thumb := Resize(img, width, height)
+
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: jpegQuality})
+	if err != nil {
+		return Image{}, err
+	}
+
+	return Image{
+		Data:        buf.Bytes(),
+		ContentType: "image/jpeg",
+		Width:       width,
+		Height:      height,
+	}, nil
+}
+
+// Fit scales width and height down to fit within size by size, keeping their
+// aspect ratio. Dimensions that already fit are returned unchanged.
+func Fit(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+
+	return max(1, width*size/height), size
+}
+
+// Resize scales img to width by height over a white background, averaging
+// the source pixels that fall under each destination pixel.
+func Resize(img image.Image, width, height int) *image.RGBA {
+	src := flatten(img)
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for dy := range height {
+		y0 := dy * srcHeight / height
+		y1 := max((dy+1)*srcHeight/height, y0+1)
+
+		for dx := range width {
+			x0 := dx * srcWidth / width
+			x1 := max((dx+1)*srcWidth/width, x0+1)
+
+			var r, g, b, n int
+			for y := y0; y < y1; y++ {
+				row := src.Pix[y*src.Stride:]
+				for x := x0; x < x1; x++ {
+					r += int(row[x*4])
+					g += int(row[x*4+1])
+					b += int(row[x*4+2])
+					n++
+				}
+			}
+
+			i := dst.PixOffset(dx, dy)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = 0xff
+		}
+	}
+
+	return dst
+}
+
+// flatten draws img over a white background into an RGBA image whose bounds
+// start at the origin.
+func flatten(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	canvas := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(canvas, canvas.Bounds(), img, b.Min, draw.Over)
+	return canvas
+}
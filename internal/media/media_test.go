@@ -0,0 +1,266 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+func testImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 0x80, A: 0xff})
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withExif inserts an APP1 segment carrying a little-endian TIFF header with
+// a single orientation entry right after the SOI marker of a JPEG.
+func withExif(data []byte, orientation uint16) []byte {
+	tiff := []byte{
+		'I', 'I', 0x2a, 0x00, 0x08, 0x00, 0x00, 0x00,
+		0x01, 0x00,
+		0x12, 0x01, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00, byte(orientation), 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	length := len(payload) + 2
+
+	segment := append([]byte{0xff, 0xe1, byte(length >> 8), byte(length)}, payload...)
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func TestProcess(t *testing.T) {
+	var pngBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, testImage(800, 200)); err != nil {
+		t.Fatal(err)
+	}
+
+	var gifBuf bytes.Buffer
+	frame := image.NewPaletted(image.Rect(0, 0, 20, 10), color.Palette{color.Black, color.White})
+	anim := &gif.GIF{Image: []*image.Paletted{frame, frame}, Delay: []int{10, 10}}
+	if err := gif.EncodeAll(&gifBuf, anim); err != nil {
+		t.Fatal(err)
+	}
+
+	var longGIFBuf bytes.Buffer
+	long := &gif.GIF{}
+	for range MaxFrames + 1 {
+		long.Image = append(long.Image, frame)
+		long.Delay = append(long.Delay, 10)
+	}
+	if err := gif.EncodeAll(&longGIFBuf, long); err != nil {
+		t.Fatal(err)
+	}
+
+	jpegData := encodeJPEG(t, testImage(300, 100))
+
+	cases := []struct {
+		name                string
+		data                []byte
+		expectedContentType string
+		expectedWidth       int
+		expectedHeight      int
+		expectedThumbWidth  int
+		expectedThumbHeight int
+		expectErr           error
+	}{
+		{
+			name:                "PNG is scaled down",
+			data:                pngBuf.Bytes(),
+			expectedContentType: "image/png",
+			expectedWidth:       800,
+			expectedHeight:      200,
+			expectedThumbWidth:  400,
+			expectedThumbHeight: 100,
+		},
+		{
+			name:                "Small JPEG keeps its size",
+			data:                jpegData,
+			expectedContentType: "image/jpeg",
+			expectedWidth:       300,
+			expectedHeight:      100,
+			expectedThumbWidth:  300,
+			expectedThumbHeight: 100,
+		},
+		{
+			name:                "JPEG is rotated upright",
+			data:                withExif(jpegData, 6),
+			expectedContentType: "image/jpeg",
+			expectedWidth:       100,
+			expectedHeight:      300,
+			expectedThumbWidth:  100,
+			expectedThumbHeight: 300,
+		},
+		{
+			name:                "Animated GIF",
+			data:                gifBuf.Bytes(),
+			expectedContentType: "image/gif",
+			expectedWidth:       20,
+			expectedHeight:      10,
+			expectedThumbWidth:  20,
+			expectedThumbHeight: 10,
+		},
+		{
+			name:      "GIF with too many frames",
+			data:      longGIFBuf.Bytes(),
+			expectErr: ErrTooLarge,
+		},
+		{
+			name:      "Not an image",
+			data:      []byte("hello, world"),
+			expectErr: ErrUnsupportedType,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			original, thumbnail, err := Process(c.data)
+			if !errors.Is(err, c.expectErr) {
+				t.Fatalf("expected error %v, got %v", c.expectErr, err)
+			}
+			if c.expectErr != nil {
+				return
+			}
+
+			if original.ContentType != c.expectedContentType {
+				t.Errorf("expected content type %v, got %v", c.expectedContentType, original.ContentType)
+			}
+
+			if original.Width != c.expectedWidth || original.Height != c.expectedHeight {
+				t.Errorf("expected %vx%v, got %vx%v", c.expectedWidth, c.expectedHeight, original.Width, original.Height)
+			}
+
+			if thumbnail.Width != c.expectedThumbWidth || thumbnail.Height != c.expectedThumbHeight {
+				t.Errorf("expected thumbnail %vx%v, got %vx%v", c.expectedThumbWidth, c.expectedThumbHeight, thumbnail.Width, thumbnail.Height)
+			}
+
+			if bytes.Contains(original.Data, []byte("Exif")) {
+				t.Errorf("expected EXIF to be stripped")
+			}
+		})
+	}
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	data := encodeJPEG(t, testImage(4, 4))
+
+	cases := []struct {
+		name     string
+		data     []byte
+		expected int
+	}{
+		{name: "No EXIF", data: data, expected: 1},
+		{name: "Rotated", data: withExif(data, 8), expected: 8},
+		{name: "Out of range", data: withExif(data, 42), expected: 1},
+		{name: "Truncated", data: withExif(data, 6)[:12], expected: 1},
+		{name: "Not a JPEG", data: []byte("GIF89a"), expected: 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := jpegOrientation(c.data)
+			if actual != c.expected {
+				t.Errorf("expected %v, got %v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFit(t *testing.T) {
+	cases := []struct {
+		name           string
+		width          int
+		height         int
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{name: "Already fits", width: 100, height: 50, expectedWidth: 100, expectedHeight: 50},
+		{name: "Landscape", width: 1600, height: 1200, expectedWidth: 400, expectedHeight: 300},
+		{name: "Portrait", width: 1000, height: 2000, expectedWidth: 200, expectedHeight: 400},
+		{name: "Very thin", width: 10000, height: 2, expectedWidth: 400, expectedHeight: 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			width, height := Fit(c.width, c.height, 400)
+			if width != c.expectedWidth || height != c.expectedHeight {
+				t.Errorf("expected %vx%v, got %vx%v", c.expectedWidth, c.expectedHeight, width, height)
+			}
+		})
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	cases := []struct {
+		name      string
+		key       string
+		expectErr error
+	}{
+		{name: "Plain key", key: "abc.jpg"},
+		{name: "Nested key", key: "thumbs/abc.jpg"},
+		{name: "Parent directory", key: "../abc.jpg", expectErr: ErrInvalidKey},
+		{name: "Absolute path", key: "/etc/passwd", expectErr: ErrInvalidKey},
+		{name: "Hidden file", key: ".upload-123", expectErr: ErrInvalidKey},
+		{name: "Unclean path", key: "a//b.jpg", expectErr: ErrInvalidKey},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := store.Put(ctx, c.key, strings.NewReader(c.name))
+			if !errors.Is(err, c.expectErr) {
+				t.Fatalf("expected error %v, got %v", c.expectErr, err)
+			}
+			if c.expectErr != nil {
+				return
+			}
+
+			f, err := store.Open(ctx, c.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := io.ReadAll(f)
+			f.Close()
+			if err != nil || string(data) != c.name {
+				t.Errorf("expected %q, got %q (%v)", c.name, data, err)
+			}
+
+			err = store.Delete(ctx, c.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = store.Open(ctx, c.key)
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected %v after delete, got %v", ErrNotFound, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package moderation
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Config describes a moderation pipeline. Filters run in the order word lists,
+// regex rules, then the link blocklist.
+type Config struct {
+	WordLists     []WordListConfig `json:"word_lists"`
+	RegexRules    []RegexConfig    `json:"regex_rules"`
+	LinkBlocklist *LinkConfig      `json:"link_blocklist"`
+}
+
+// WordListConfig lists banned words inline, in a file with one word per line,
+// or both. Lines in the file starting with '#' are ignored.
+type WordListConfig struct {
+	Name   string   `json:"name"`
+	Action string   `json:"action"`
+	Words  []string `json:"words"`
+	File   string   `json:"file"`
+}
+
+type RegexConfig struct {
+	Pattern string `json:"pattern"`
+	Action  string `json:"action"`
+	Reason  string `json:"reason"`
+}
+
+type LinkConfig struct {
+	Action  string   `json:"action"`
+	Domains []string `json:"domains"`
+}
+
+// DefaultConfig masks the words chirpy has always filtered.
+func DefaultConfig() Config {
+	return Config{
+		WordLists: []WordListConfig{
+			{
+				Name:   "profanity",
+				Action: "mask",
+				Words:  []string{"kerfuffle", "sharbert", "fornax"},
+			},
+		},
+	}
+}
+
+func LoadConfig(path string) (Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Config{}, err
+	}
+	defer file.Close()
+
+	var config Config
+	err = json.NewDecoder(file).Decode(&config)
+	if err != nil {
+		return Config{}, fmt.Errorf("Invalid moderation config %v: %w", path, err)
+	}
+
+	return config, nil
+}
+
+func (c Config) Pipeline() (*Pipeline, error) {
+	var filters []Filter
+
+	for i, list := range c.WordLists {
+		action, err := ParseAction(list.Action)
+		if err != nil {
+			return nil, err
+		}
+
+		words := list.Words
+		if list.File != "" {
+			fileWords, err := readWordFile(list.File)
+			if err != nil {
+				return nil, err
+			}
+			words = append(words, fileWords...)
+		}
+
+		name := list.Name
+		if name == "" {
+			name = fmt.Sprintf("word_list_%d", i)
+		}
+		filters = append(filters, NewWordListFilter(name, action, words))
+	}
+
+	if len(c.RegexRules) > 0 {
+		var rules []RegexRule
+		for _, rule := range c.RegexRules {
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid moderation pattern %q: %w", rule.Pattern, err)
+			}
+
+			action, err := ParseAction(rule.Action)
+			if err != nil {
+				return nil, err
+			}
+
+			rules = append(rules, RegexRule{Pattern: pattern, Action: action, Reason: rule.Reason})
+		}
+		filters = append(filters, NewRegexFilter("regex", rules))
+	}
+
+	if c.LinkBlocklist != nil && len(c.LinkBlocklist.Domains) > 0 {
+		action, err := ParseAction(c.LinkBlocklist.Action)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, NewLinkFilter("link_blocklist", action, c.LinkBlocklist.Domains))
+	}
+
+	return NewPipeline(filters...), nil
+}
+
+func readWordFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+
+	return words, scanner.Err()
+}
@@ -0,0 +1,175 @@
+package moderation
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const maskText = "****"
+
+// WordListFilter matches whole words against a list of banned words. Both the
+// list and the text are normalized, so "Kerfuffle!" and "k3rfuffl3" match
+// "kerfuffle".
+type WordListFilter struct {
+	name   string
+	action Action
+	words  map[string]struct{}
+}
+
+func NewWordListFilter(name string, action Action, words []string) *WordListFilter {
+	filter := &WordListFilter{name: name, action: action, words: map[string]struct{}{}}
+	for _, word := range words {
+		word = Normalize(strings.TrimSpace(word))
+		if word != "" {
+			filter.words[word] = struct{}{}
+		}
+	}
+
+	return filter
+}
+
+func (f *WordListFilter) Name() string {
+	return f.name
+}
+
+func (f *WordListFilter) Apply(body string) (string, Decision) {
+	decision := Decision{Filter: f.name, Action: Allow}
+
+	var b strings.Builder
+	last := 0
+	for _, token := range Tokenize(body) {
+		if _, ok := f.words[token.Norm]; !ok {
+			continue
+		}
+
+		decision.Matches = append(decision.Matches, token.Text)
+		b.WriteString(body[last:token.Start])
+		b.WriteString(maskText)
+		last = token.End
+	}
+
+	if len(decision.Matches) == 0 {
+		return body, decision
+	}
+
+	b.WriteString(body[last:])
+	decision.Action = f.action
+	decision.Reason = "matched banned word"
+
+	return b.String(), decision
+}
+
+// RegexRule is a single pattern checked by a RegexFilter.
+type RegexRule struct {
+	Pattern *regexp.Regexp
+	Action  Action
+	Reason  string
+}
+
+// RegexFilter checks the text against a set of regular expressions. When more
+// than one rule matches, the most severe action wins.
+type RegexFilter struct {
+	name  string
+	rules []RegexRule
+}
+
+func NewRegexFilter(name string, rules []RegexRule) *RegexFilter {
+	return &RegexFilter{name: name, rules: rules}
+}
+
+func (f *RegexFilter) Name() string {
+	return f.name
+}
+
+func (f *RegexFilter) Apply(body string) (string, Decision) {
+	decision := Decision{Filter: f.name, Action: Allow}
+	masked := body
+
+	for _, rule := range f.rules {
+		matches := rule.Pattern.FindAllString(masked, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		decision.Matches = append(decision.Matches, matches...)
+		if rule.Action >= decision.Action {
+			decision.Action = rule.Action
+			decision.Reason = rule.Reason
+		}
+
+		if rule.Action == Mask {
+			masked = rule.Pattern.ReplaceAllLiteralString(masked, maskText)
+		}
+	}
+
+	return masked, decision
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+`)
+
+// LinkFilter checks links in the text against a blocklist of domains. A domain
+// on the list also blocks all of its subdomains.
+type LinkFilter struct {
+	name    string
+	action  Action
+	domains []string
+}
+
+func NewLinkFilter(name string, action Action, domains []string) *LinkFilter {
+	filter := &LinkFilter{name: name, action: action}
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.Trim(strings.TrimSpace(domain), "."))
+		if domain != "" {
+			filter.domains = append(filter.domains, domain)
+		}
+	}
+
+	return filter
+}
+
+func (f *LinkFilter) Name() string {
+	return f.name
+}
+
+func (f *LinkFilter) Apply(body string) (string, Decision) {
+	decision := Decision{Filter: f.name, Action: Allow}
+
+	masked := linkPattern.ReplaceAllStringFunc(body, func(link string) string {
+		if !f.blocked(link) {
+			return link
+		}
+
+		decision.Matches = append(decision.Matches, link)
+		return maskText
+	})
+
+	if len(decision.Matches) == 0 {
+		return body, decision
+	}
+
+	decision.Action = f.action
+	decision.Reason = "link to blocked domain"
+
+	return masked, decision
+}
+
+func (f *LinkFilter) blocked(link string) bool {
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	for _, domain := range f.domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
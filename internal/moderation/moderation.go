@@ -0,0 +1,105 @@
+package moderation
+
+import (
+	"errors"
+	"strings"
+)
+
+// Action is the outcome a filter asks for. Actions are ordered by severity so
+// that the most severe action requested by any filter in a pipeline wins.
+type Action int
+
+const (
+	Allow Action = iota
+	Mask
+	Review
+	Reject
+)
+
+func (a Action) String() string {
+	switch a {
+	case Allow:
+		return "allow"
+	case Mask:
+		return "mask"
+	case Review:
+		return "review"
+	case Reject:
+		return "reject"
+	default:
+		return "unknown"
+	}
+}
+
+func ParseAction(s string) (Action, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "allow":
+		return Allow, nil
+	case "mask":
+		return Mask, nil
+	case "review":
+		return Review, nil
+	case "reject":
+		return Reject, nil
+	default:
+		return Allow, errors.New("Unknown moderation action: " + s)
+	}
+}
+
+// Decision records what a single filter decided about a body of text.
+type Decision struct {
+	Filter  string
+	Action  Action
+	Reason  string
+	Matches []string
+}
+
+// Filter inspects a body of text. Filters that mask return the rewritten body,
+// all others return the body unchanged.
+type Filter interface {
+	Name() string
+	Apply(body string) (string, Decision)
+}
+
+// Result is the combined outcome of running a body through a Pipeline.
+type Result struct {
+	Body      string
+	Action    Action
+	Decisions []Decision
+}
+
+// Pipeline runs a chain of filters in order, feeding the output of each filter
+// into the next. It stops early once a filter rejects the body.
+type Pipeline struct {
+	filters []Filter
+}
+
+func NewPipeline(filters ...Filter) *Pipeline {
+	return &Pipeline{filters: filters}
+}
+
+func (p *Pipeline) Run(body string) Result {
+	result := Result{Body: body, Action: Allow}
+
+	for _, filter := range p.filters {
+		masked, decision := filter.Apply(result.Body)
+		if decision.Action == Allow {
+			continue
+		}
+
+		result.Decisions = append(result.Decisions, decision)
+		if decision.Action > result.Action {
+			result.Action = decision.Action
+		}
+
+		if decision.Action == Mask {
+			result.Body = masked
+		}
+
+		if decision.Action == Reject {
+			break
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,134 @@
+package moderation
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	cases := []struct {
+		name          string
+		text          string
+		expectedWords []string
+		expectedNorms []string
+	}{
+		{
+			name:          "Spaces",
+			text:          "hello big world",
+			expectedWords: []string{"hello", "big", "world"},
+			expectedNorms: []string{"hello", "big", "world"},
+		},
+		{
+			name:          "Punctuation",
+			text:          "Kerfuffle! What, a sharbert.",
+			expectedWords: []string{"Kerfuffle", "What", "a", "sharbert"},
+			expectedNorms: []string{"kerfuffle", "what", "a", "sharbert"},
+		},
+		{
+			name:          "Leetspeak",
+			text:          "k3rfuffl3 sh@rbert @fornax",
+			expectedWords: []string{"k3rfuffl3", "sh@rbert", "fornax"},
+			expectedNorms: []string{"kerfuffle", "sharbert", "fornax"},
+		},
+		{
+			name:          "Unicode",
+			text:          "Fôrnax¡ привет",
+			expectedWords: []string{"Fôrnax", "привет"},
+			expectedNorms: []string{"fornax", "привет"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var words, norms []string
+			for _, token := range Tokenize(c.text) {
+				if c.text[token.Start:token.End] != token.Text {
+					t.Errorf("Tokenize() token %q has offsets [%d:%d] that don't match the text", token.Text, token.Start, token.End)
+				}
+				words = append(words, token.Text)
+				norms = append(norms, token.Norm)
+			}
+
+			if !slices.Equal(words, c.expectedWords) {
+				t.Errorf("Tokenize() received words: %v, expected words: %v", words, c.expectedWords)
+			}
+
+			if !slices.Equal(norms, c.expectedNorms) {
+				t.Errorf("Tokenize() received norms: %v, expected norms: %v", norms, c.expectedNorms)
+			}
+		})
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	pipeline := NewPipeline(
+		NewWordListFilter("profanity", Mask, []string{"kerfuffle", "sharbert", "fornax"}),
+		NewWordListFilter("slurs", Reject, []string{"badword"}),
+		NewRegexFilter("regex", []RegexRule{
+			{Pattern: regexp.MustCompile(`\d{3}-\d{3}-\d{4}`), Action: Review, Reason: "phone number"},
+		}),
+		NewLinkFilter("links", Mask, []string{"spam.example"}),
+	)
+
+	cases := []struct {
+		name           string
+		body           string
+		expectedBody   string
+		expectedAction Action
+		expectedCount  int
+	}{
+		{
+			name:           "Clean body",
+			body:           "I had something interesting for breakfast",
+			expectedBody:   "I had something interesting for breakfast",
+			expectedAction: Allow,
+			expectedCount:  0,
+		},
+		{
+			name:           "Masked words",
+			body:           "This is a Kerfuffle! opinion I need to sh@rbert",
+			expectedBody:   "This is a ****! opinion I need to ****",
+			expectedAction: Mask,
+			expectedCount:  1,
+		},
+		{
+			name:           "Rejected word",
+			body:           "kerfuffle and a BadWord",
+			expectedBody:   "**** and a BadWord",
+			expectedAction: Reject,
+			expectedCount:  2,
+		},
+		{
+			name:           "Review pattern",
+			body:           "call me at 555-123-4567",
+			expectedBody:   "call me at 555-123-4567",
+			expectedAction: Review,
+			expectedCount:  1,
+		},
+		{
+			name:           "Blocked link",
+			body:           "see https://www.spam.example/deal and https://boot.dev",
+			expectedBody:   "see **** and https://boot.dev",
+			expectedAction: Mask,
+			expectedCount:  1,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result := pipeline.Run(c.body)
+			if result.Body != c.expectedBody {
+				t.Errorf("Run() received body: %q, expected body: %q", result.Body, c.expectedBody)
+			}
+
+			if result.Action != c.expectedAction {
+				t.Errorf("Run() received action: %v, expected action: %v", result.Action, c.expectedAction)
+			}
+
+			if len(result.Decisions) != c.expectedCount {
+				t.Errorf("Run() received %d decisions, expected %d", len(result.Decisions), c.expectedCount)
+			}
+		})
+	}
+}
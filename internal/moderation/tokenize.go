@@ -0,0 +1,128 @@
+package moderation
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token is a word found in a body of text. Start and End are byte offsets into
+// the original text and Norm is the normalized form used for matching.
+type Token struct {
+	Text  string
+	Norm  string
+	Start int
+	End   int
+}
+
+var leetspeak = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'@': 'a',
+	'$': 's',
+}
+
+var diacritics = map[rune]rune{}
+
+func init() {
+	folds := map[rune]string{
+		'a': "àáâãäåāăą",
+		'c': "çćĉċč",
+		'd': "ďđ",
+		'e': "èéêëēĕėęě",
+		'g': "ĝğġģ",
+		'h': "ĥħ",
+		'i': "ìíîïĩīĭįı",
+		'j': "ĵ",
+		'k': "ķ",
+		'l': "ĺļľŀł",
+		'n': "ñńņňŉ",
+		'o': "òóôõöøōŏő",
+		'r': "ŕŗř",
+		's': "śŝşšß",
+		't': "ţťŧ",
+		'u': "ùúûüũūŭůűų",
+		'w': "ŵ",
+		'y': "ýÿŷ",
+		'z': "źżž",
+	}
+
+	for base, variants := range folds {
+		for _, variant := range variants {
+			diacritics[variant] = base
+		}
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r)
+}
+
+func isLeetSymbol(r rune) bool {
+	return r == '@' || r == '$'
+}
+
+// Tokenize splits text into words. Words are runs of letters, digits and
+// combining marks in any script. Leetspeak symbols such as '@' and '$' are only
+// treated as part of a word when they sit between two word characters, so
+// "sh@rbert" is one word while "@sharbert" and "sharbert$" are not.
+func Tokenize(text string) []Token {
+	var tokens []Token
+
+	start := -1
+	for i, r := range text {
+		inWord := isWordRune(r)
+		if !inWord && isLeetSymbol(r) && start >= 0 {
+			next, _ := utf8.DecodeRuneInString(text[i+utf8.RuneLen(r):])
+			inWord = isWordRune(next)
+		}
+
+		if inWord && start < 0 {
+			start = i
+		} else if !inWord && start >= 0 {
+			tokens = append(tokens, newToken(text, start, i))
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		tokens = append(tokens, newToken(text, start, len(text)))
+	}
+
+	return tokens
+}
+
+func newToken(text string, start, end int) Token {
+	word := text[start:end]
+	return Token{Text: word, Norm: Normalize(word), Start: start, End: end}
+}
+
+// Normalize lowercases a word, folds common Latin diacritics, drops combining
+// marks and undoes leetspeak substitutions.
+func Normalize(word string) string {
+	var b strings.Builder
+	b.Grow(len(word))
+
+	for _, r := range word {
+		if unicode.IsMark(r) {
+			continue
+		}
+
+		r = unicode.ToLower(r)
+		if folded, ok := diacritics[r]; ok {
+			r = folded
+		}
+		if plain, ok := leetspeak[r]; ok {
+			r = plain
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
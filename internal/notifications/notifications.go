@@ -0,0 +1,136 @@
+// Package notifications groups and describes the notifications users get
+// when others interact with them.
+package notifications
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Kind string
+
+const (
+	Follow        Kind = "follow"
+	FollowRequest Kind = "follow_request"
+	Mention       Kind = "mention"
+	// ReportResolved tells a user a moderator has dealt with their report.
+	// Its actor is the reporter, since moderators stay anonymous.
+	ReportResolved Kind = "report_resolved"
+)
+
+// Kinds lists every kind of notification users can turn on and off.
+var Kinds = []Kind{Follow, FollowRequest, Mention, ReportResolved}
+
+func ParseKind(s string) (Kind, bool) {
+	for _, kind := range Kinds {
+		if string(kind) == s {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// GroupKey is what notifications are grouped and deduplicated by. All follows
+// group together, as do all follow requests, while the other kinds group per
+// chirp.
+func GroupKey(kind Kind, chirpID uuid.NullUUID) string {
+	if !chirpID.Valid {
+		return string(kind)
+	}
+	return string(kind) + ":" + chirpID.UUID.String()
+}
+
+// ReportGroupKey is the group key of a ReportResolved notification. Every
+// report is resolved on its own.
+func ReportGroupKey(reportID uuid.UUID) string {
+	return string(ReportResolved) + ":" + reportID.String()
+}
+
+type Notification struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	Kind        Kind
+	ChirpID     uuid.NullUUID
+	GroupKey    string
+	ActorID     uuid.UUID
+	ActorHandle string
+	Read        bool
+}
+
+// Group is a run of notifications with the same group key, such as everyone
+// who mentioned the user in one chirp.
+type Group struct {
+	Kind         Kind
+	ChirpID      uuid.NullUUID
+	IDs          []uuid.UUID
+	ActorIDs     []uuid.UUID
+	ActorHandles []string
+	LatestAt     time.Time
+	// Read is true once every notification in the group has been read.
+	Read bool
+}
+
+// GroupNotifications merges notifications that share a group key. The input
+// is expected newest first, and groups are ordered by their newest member.
+func GroupNotifications(notifications []Notification) []Group {
+	groups := []Group{}
+	index := map[string]int{}
+
+	for _, n := range notifications {
+		i, ok := index[n.GroupKey]
+		if !ok {
+			i = len(groups)
+			index[n.GroupKey] = i
+			groups = append(groups, Group{
+				Kind:     n.Kind,
+				ChirpID:  n.ChirpID,
+				LatestAt: n.CreatedAt,
+				Read:     true,
+			})
+		}
+
+		g := &groups[i]
+		g.IDs = append(g.IDs, n.ID)
+		g.ActorIDs = append(g.ActorIDs, n.ActorID)
+		g.ActorHandles = append(g.ActorHandles, n.ActorHandle)
+		g.Read = g.Read && n.Read
+	}
+
+	return groups
+}
+
+// Summary describes the group in a sentence such as "@alice and 4 others
+// mentioned you".
+func (g Group) Summary() string {
+	if g.Kind == ReportResolved {
+		return "A moderator reviewed your report"
+	}
+
+	var actors string
+	switch len(g.ActorHandles) {
+	case 0:
+		actors = "Someone"
+	case 1:
+		actors = "@" + g.ActorHandles[0]
+	case 2:
+		actors = "@" + g.ActorHandles[0] + " and @" + g.ActorHandles[1]
+	default:
+		actors = fmt.Sprintf("@%s and %d others", g.ActorHandles[0], len(g.ActorHandles)-1)
+	}
+
+	var action string
+	switch g.Kind {
+	case Follow:
+		action = "followed you"
+	case FollowRequest:
+		action = "requested to follow you"
+	case Mention:
+		action = "mentioned you"
+	default:
+		action = "interacted with you"
+	}
+
+	return actors + " " + action
+}
@@ -0,0 +1,91 @@
+package notifications
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestGroupNotifications(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	chirpID := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+
+	notifications := []Notification{
+		{ID: ids[0], CreatedAt: now, Kind: Mention, ChirpID: chirpID, GroupKey: GroupKey(Mention, chirpID), ActorID: alice, ActorHandle: "alice"},
+		{ID: ids[1], CreatedAt: now.Add(-time.Minute), Kind: Follow, GroupKey: GroupKey(Follow, uuid.NullUUID{}), ActorID: bob, ActorHandle: "bob", Read: true},
+		{ID: ids[2], CreatedAt: now.Add(-2 * time.Minute), Kind: Mention, ChirpID: chirpID, GroupKey: GroupKey(Mention, chirpID), ActorID: bob, ActorHandle: "bob", Read: true},
+		{ID: ids[3], CreatedAt: now.Add(-3 * time.Minute), Kind: Mention, ChirpID: chirpID, GroupKey: GroupKey(Mention, chirpID), ActorID: carol, ActorHandle: "carol"},
+	}
+
+	expected := []Group{
+		{
+			Kind:         Mention,
+			ChirpID:      chirpID,
+			IDs:          []uuid.UUID{ids[0], ids[2], ids[3]},
+			ActorIDs:     []uuid.UUID{alice, bob, carol},
+			ActorHandles: []string{"alice", "bob", "carol"},
+			LatestAt:     now,
+			Read:         false,
+		},
+		{
+			Kind:         Follow,
+			IDs:          []uuid.UUID{ids[1]},
+			ActorIDs:     []uuid.UUID{bob},
+			ActorHandles: []string{"bob"},
+			LatestAt:     now.Add(-time.Minute),
+			Read:         true,
+		},
+	}
+
+	actual := GroupNotifications(notifications)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	cases := []struct {
+		name     string
+		group    Group
+		expected string
+	}{
+		{
+			name:     "One follower",
+			group:    Group{Kind: Follow, ActorHandles: []string{"alice"}},
+			expected: "@alice followed you",
+		},
+		{
+			name:     "Follow requests",
+			group:    Group{Kind: FollowRequest, ActorHandles: []string{"alice", "bob"}},
+			expected: "@alice and @bob requested to follow you",
+		},
+		{
+			name:     "Two mentions",
+			group:    Group{Kind: Mention, ActorHandles: []string{"alice", "bob"}},
+			expected: "@alice and @bob mentioned you",
+		},
+		{
+			name:     "Many mentions",
+			group:    Group{Kind: Mention, ActorHandles: []string{"alice", "bob", "carol", "dave", "erin"}},
+			expected: "@alice and 4 others mentioned you",
+		},
+		{
+			name:     "Report resolved",
+			group:    Group{Kind: ReportResolved, ActorHandles: []string{"alice"}},
+			expected: "A moderator reviewed your report",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := c.group.Summary()
+			if actual != c.expected {
+				t.Errorf("expected %q, got %q", c.expected, actual)
+			}
+		})
+	}
+}
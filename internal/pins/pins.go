@@ -0,0 +1,49 @@
+// Package pins holds the rules for chirps pinned to the top of a profile.
+package pins
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxPins    = 3
+	MaxRedPins = 10
+)
+
+var ErrNotAuthor = errors.New("can only pin your own chirps")
+
+// Limit is how many chirps a user may pin. Pins over the limit, left behind
+// when a Chirpy Red subscription lapses, are kept but not shown.
+func Limit(isChirpyRed bool) int {
+	if isChirpyRed {
+		return MaxRedPins
+	}
+
+	return MaxPins
+}
+
+// ValidateOrder checks that a requested order lists exactly the pinned chirps,
+// each once.
+func ValidateOrder(pinned, order []uuid.UUID) error {
+	if len(order) != len(pinned) {
+		return fmt.Errorf("order must list all %d pinned chirps", len(pinned))
+	}
+
+	seen := map[uuid.UUID]bool{}
+	for _, id := range order {
+		if !slices.Contains(pinned, id) {
+			return fmt.Errorf("chirp %s isn't pinned", id)
+		}
+
+		if seen[id] {
+			return fmt.Errorf("chirp %s listed twice", id)
+		}
+		seen[id] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package pins
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestLimit(t *testing.T) {
+	if got := Limit(false); got != MaxPins {
+		t.Errorf("expected %d pins, got %d", MaxPins, got)
+	}
+
+	if got := Limit(true); got != MaxRedPins {
+		t.Errorf("expected %d pins for Chirpy Red, got %d", MaxRedPins, got)
+	}
+}
+
+func TestValidateOrder(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+
+	cases := []struct {
+		name          string
+		pinned        []uuid.UUID
+		order         []uuid.UUID
+		expectedError bool
+	}{
+		{
+			name:   "Same order",
+			pinned: []uuid.UUID{a, b},
+			order:  []uuid.UUID{a, b},
+		},
+		{
+			name:   "Reversed",
+			pinned: []uuid.UUID{a, b},
+			order:  []uuid.UUID{b, a},
+		},
+		{
+			name:   "Nothing pinned",
+			pinned: []uuid.UUID{},
+			order:  []uuid.UUID{},
+		},
+		{
+			name:          "Missing chirp",
+			pinned:        []uuid.UUID{a, b},
+			order:         []uuid.UUID{a},
+			expectedError: true,
+		},
+		{
+			name:          "Unpinned chirp",
+			pinned:        []uuid.UUID{a, b},
+			order:         []uuid.UUID{a, c},
+			expectedError: true,
+		},
+		{
+			name:          "Duplicate chirp",
+			pinned:        []uuid.UUID{a, b},
+			order:         []uuid.UUID{a, a},
+			expectedError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateOrder(c.pinned, c.order)
+			if (err != nil) != c.expectedError {
+				t.Errorf("expected error: %v, got: %v", c.expectedError, err)
+			}
+		})
+	}
+}
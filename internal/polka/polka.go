@@ -0,0 +1,123 @@
+// Package polka describes the webhooks sent by Polka, Chirpy's payment
+// provider, and verifies their signatures.
+package polka
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Events that change a subscription. Others are acknowledged and ignored.
+const (
+	UserUpgraded         = "user.upgraded"
+	UserDowngraded       = "user.downgraded"
+	SubscriptionRenewed  = "subscription.renewed"
+	SubscriptionCanceled = "subscription.canceled"
+)
+
+const (
+	// SignatureHeader holds "t=<unix seconds>,v1=<hex HMAC-SHA256>". The HMAC
+	// covers the timestamp, a dot and the raw body.
+	SignatureHeader = "X-Polka-Signature"
+	// DefaultTolerance is how far a signature's timestamp may be from now
+	// before it's rejected as a replay.
+	DefaultTolerance = 5 * time.Minute
+)
+
+var (
+	ErrNoSecret         = errors.New("no webhook secret is configured")
+	ErrMissingSignature = errors.New("missing signature")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrStaleSignature   = errors.New("signature timestamp is outside the tolerance")
+)
+
+// Event is the body of a webhook. EventID is unique per event and repeated
+// when Polka retries a delivery.
+type Event struct {
+	EventID string    `json:"event_id"`
+	Event   string    `json:"event"`
+	Data    EventData `json:"data"`
+}
+
+type EventData struct {
+	UserID      uuid.UUID  `json:"user_id"`
+	Plan        string     `json:"plan,omitempty"`
+	PeriodStart *time.Time `json:"period_start,omitempty"`
+	PeriodEnd   *time.Time `json:"period_end,omitempty"`
+}
+
+// Sign returns the signature header value for a body sent at timestamp.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := timestamp.Unix()
+	return fmt.Sprintf("t=%d,v1=%s", t, hex.EncodeToString(mac(secret, t, body)))
+}
+
+// Verify checks a signature header against the raw body. Any of several v1
+// values may match, so the secret can be rotated without dropping events.
+func Verify(header string, body []byte, secret string, now time.Time, tolerance time.Duration) error {
+	// Anyone can sign with an empty key.
+	if secret == "" {
+		return ErrNoSecret
+	}
+
+	if header == "" {
+		return ErrMissingSignature
+	}
+
+	var timestamp int64
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrInvalidSignature
+		}
+
+		switch key {
+		case "t":
+			t, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return ErrInvalidSignature
+			}
+			timestamp = t
+		case "v1":
+			signature, err := hex.DecodeString(value)
+			if err != nil {
+				return ErrInvalidSignature
+			}
+			signatures = append(signatures, signature)
+		}
+	}
+
+	if timestamp == 0 || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	age := now.Sub(time.Unix(timestamp, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrStaleSignature
+	}
+
+	expected := mac(secret, timestamp, body)
+	for _, signature := range signatures {
+		if hmac.Equal(signature, expected) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+func mac(secret string, timestamp int64, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(h, "%d.", timestamp)
+	h.Write(body)
+	return h.Sum(nil)
+}
@@ -0,0 +1,102 @@
+package polka
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	const secret = "polka-secret"
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"event_id":"1","event":"user.upgraded"}`)
+
+	// While a secret is rotated, Polka signs with both the old and new one.
+	_, current, _ := strings.Cut(Sign(secret, now, body), ",")
+	rotated := Sign("old-secret", now, body) + "," + current
+
+	cases := []struct {
+		name          string
+		header        string
+		body          []byte
+		expectedError error
+	}{
+		{
+			name:   "Valid signature",
+			header: Sign(secret, now, body),
+			body:   body,
+		},
+		{
+			name:   "Within tolerance",
+			header: Sign(secret, now.Add(-4*time.Minute), body),
+			body:   body,
+		},
+		{
+			name:   "Rotated secret",
+			header: rotated,
+			body:   body,
+		},
+		{
+			name:          "Missing header",
+			header:        "",
+			body:          body,
+			expectedError: ErrMissingSignature,
+		},
+		{
+			name:          "Wrong secret",
+			header:        Sign("other-secret", now, body),
+			body:          body,
+			expectedError: ErrInvalidSignature,
+		},
+		{
+			name:          "Tampered body",
+			header:        Sign(secret, now, body),
+			body:          []byte(`{"event_id":"2","event":"user.upgraded"}`),
+			expectedError: ErrInvalidSignature,
+		},
+		{
+			name:          "Too old",
+			header:        Sign(secret, now.Add(-6*time.Minute), body),
+			body:          body,
+			expectedError: ErrStaleSignature,
+		},
+		{
+			name:          "Too far ahead",
+			header:        Sign(secret, now.Add(6*time.Minute), body),
+			body:          body,
+			expectedError: ErrStaleSignature,
+		},
+		{
+			name:          "Malformed",
+			header:        "t=abc,v1=zz",
+			body:          body,
+			expectedError: ErrInvalidSignature,
+		},
+		{
+			name:          "No signature",
+			header:        "t=1767268800",
+			body:          body,
+			expectedError: ErrInvalidSignature,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := Verify(c.header, c.body, secret, now, DefaultTolerance)
+			if !errors.Is(err, c.expectedError) {
+				t.Errorf("expected error %v, got %v", c.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestVerifyWithoutSecret(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"event_id":"1","event":"user.upgraded"}`)
+
+	err := Verify(Sign("", now, body), body, "", now, DefaultTolerance)
+	if !errors.Is(err, ErrNoSecret) {
+		t.Errorf("expected error %v, got %v", ErrNoSecret, err)
+	}
+}
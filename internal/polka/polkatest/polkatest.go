@@ -0,0 +1,67 @@
+// Package polkatest is a fake Polka for local testing. It sends webhooks
+// signed the way Polka signs them.
+package polkatest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/polka"
+)
+
+type Sender struct {
+	// URL is the webhook endpoint, e.g. http://localhost:8080/api/polka/webhooks.
+	URL    string
+	APIKey string
+	Secret string
+	Client *http.Client
+	// Now stands in for time.Now, so tests can send stale signatures.
+	Now func() time.Time
+}
+
+// NewEvent returns an event with a fresh ID.
+func NewEvent(kind string, userID uuid.UUID) polka.Event {
+	return polka.Event{
+		EventID: uuid.NewString(),
+		Event:   kind,
+		Data:    polka.EventData{UserID: userID},
+	}
+}
+
+// Send delivers an event. Sending the same event again replays it with the
+// same event_id, the way Polka retries.
+func (s *Sender) Send(ctx context.Context, event polka.Event) (*http.Response, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.SendRaw(ctx, body)
+}
+
+// SendRaw signs and delivers a body as is.
+func (s *Sender) SendRaw(ctx context.Context, body []byte) (*http.Response, error) {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "ApiKey "+s.APIKey)
+	req.Header.Set(polka.SignatureHeader, polka.Sign(s.Secret, now(), body))
+
+	return client.Do(req)
+}
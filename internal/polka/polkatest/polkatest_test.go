@@ -0,0 +1,82 @@
+package polkatest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/polka"
+)
+
+func TestSenderSignsEvents(t *testing.T) {
+	const secret = "polka-secret"
+
+	received := []polka.Event{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		err := polka.Verify(r.Header.Get(polka.SignatureHeader), body, secret, time.Now(), polka.DefaultTolerance)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "ApiKey polka-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		event := polka.Event{}
+		json.Unmarshal(body, &event)
+		received = append(received, event)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name     string
+		secret   string
+		now      func() time.Time
+		expected int
+	}{
+		{
+			name:     "Signed",
+			secret:   secret,
+			expected: http.StatusNoContent,
+		},
+		{
+			name:     "Wrong secret",
+			secret:   "other-secret",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "Stale",
+			secret:   secret,
+			now:      func() time.Time { return time.Now().Add(-time.Hour) },
+			expected: http.StatusUnauthorized,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sender := Sender{URL: server.URL, APIKey: "polka-key", Secret: c.secret, Now: c.now}
+			resp, err := sender.Send(context.Background(), NewEvent(polka.UserUpgraded, uuid.New()))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != c.expected {
+				t.Errorf("expected status %d, got %d", c.expected, resp.StatusCode)
+			}
+		})
+	}
+
+	if len(received) != 1 || received[0].Event != polka.UserUpgraded {
+		t.Errorf("expected one user.upgraded event, got %v", received)
+	}
+}
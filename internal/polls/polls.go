@@ -0,0 +1,103 @@
+// Package polls validates the polls attached to chirps and the votes cast in
+// them.
+package polls
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MinOptions      = 2
+	MaxOptions      = 4
+	MaxOptionLength = 25
+
+	MinDuration = 5 * time.Minute
+	MaxDuration = 7 * 24 * time.Hour
+)
+
+var (
+	ErrAlreadyVoted = errors.New("already voted in this poll")
+	ErrClosed       = errors.New("poll is closed")
+)
+
+// ValidateOptions checks the option texts of a new poll and returns them
+// trimmed.
+func ValidateOptions(options []string) ([]string, error) {
+	if len(options) < MinOptions || len(options) > MaxOptions {
+		return nil, fmt.Errorf("poll must have %d to %d options", MinOptions, MaxOptions)
+	}
+
+	trimmed := []string{}
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return nil, errors.New("poll options can't be empty")
+		}
+
+		if utf8.RuneCountInString(option) > MaxOptionLength {
+			return nil, fmt.Errorf("poll options can't be longer than %d characters", MaxOptionLength)
+		}
+
+		if slices.Contains(trimmed, option) {
+			return nil, errors.New("poll options must be different")
+		}
+
+		trimmed = append(trimmed, option)
+	}
+
+	return trimmed, nil
+}
+
+// ValidateClosesAt checks that a new poll stays open for a sensible time.
+func ValidateClosesAt(closesAt, now time.Time) error {
+	open := closesAt.Sub(now)
+	if open < MinDuration {
+		return fmt.Errorf("poll must stay open for at least %v", MinDuration)
+	}
+
+	if open > MaxDuration {
+		return fmt.Errorf("poll can't stay open for more than %v", MaxDuration)
+	}
+
+	return nil
+}
+
+// ValidateChoices checks a ballot against a poll with optionCount options and
+// returns the chosen positions in order.
+func ValidateChoices(choices []int, optionCount int, multipleChoice bool) ([]int32, error) {
+	if len(choices) == 0 {
+		return nil, errors.New("choose at least one option")
+	}
+
+	if !multipleChoice && len(choices) > 1 {
+		return nil, errors.New("poll allows only one choice")
+	}
+
+	positions := []int32{}
+	for _, choice := range choices {
+		if choice < 0 || choice >= optionCount {
+			return nil, fmt.Errorf("invalid option %d", choice)
+		}
+
+		if slices.Contains(positions, int32(choice)) {
+			return nil, fmt.Errorf("option %d chosen twice", choice)
+		}
+
+		positions = append(positions, int32(choice))
+	}
+
+	slices.Sort(positions)
+	return positions, nil
+}
+
+// ShowResults reports whether a viewer may see the tallies. They stay hidden
+// until the viewer has voted or the poll has closed, so early results don't
+// sway anyone.
+func ShowResults(voted bool, closesAt, now time.Time) bool {
+	return voted || !now.Before(closesAt)
+}
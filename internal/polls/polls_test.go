@@ -0,0 +1,155 @@
+package polls
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestValidateOptions(t *testing.T) {
+	cases := []struct {
+		name          string
+		options       []string
+		expected      []string
+		expectedError bool
+	}{
+		{
+			name:     "Two options",
+			options:  []string{" Yes ", "No"},
+			expected: []string{"Yes", "No"},
+		},
+		{
+			name:          "One option",
+			options:       []string{"Yes"},
+			expectedError: true,
+		},
+		{
+			name:          "Five options",
+			options:       []string{"a", "b", "c", "d", "e"},
+			expectedError: true,
+		},
+		{
+			name:          "Empty option",
+			options:       []string{"Yes", "  "},
+			expectedError: true,
+		},
+		{
+			name:          "Duplicate options",
+			options:       []string{"Yes", "Yes"},
+			expectedError: true,
+		},
+		{
+			name:          "Option too long",
+			options:       []string{"Yes", "This option is far too long to fit"},
+			expectedError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual, err := ValidateOptions(c.options)
+			if (err != nil) != c.expectedError {
+				t.Fatalf("expected error: %v, got: %v", c.expectedError, err)
+			}
+
+			if !slices.Equal(actual, c.expected) {
+				t.Errorf("expected %q, got %q", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidateClosesAt(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name          string
+		closesAt      time.Time
+		expectedError bool
+	}{
+		{
+			name:     "One day",
+			closesAt: now.Add(24 * time.Hour),
+		},
+		{
+			name:          "Already closed",
+			closesAt:      now.Add(-time.Minute),
+			expectedError: true,
+		},
+		{
+			name:          "Too short",
+			closesAt:      now.Add(time.Minute),
+			expectedError: true,
+		},
+		{
+			name:          "Too long",
+			closesAt:      now.Add(8 * 24 * time.Hour),
+			expectedError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateClosesAt(c.closesAt, now)
+			if (err != nil) != c.expectedError {
+				t.Errorf("expected error: %v, got: %v", c.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestValidateChoices(t *testing.T) {
+	cases := []struct {
+		name           string
+		choices        []int
+		multipleChoice bool
+		expected       []int32
+		expectedError  bool
+	}{
+		{
+			name:     "Single choice",
+			choices:  []int{1},
+			expected: []int32{1},
+		},
+		{
+			name:           "Multiple choices are sorted",
+			choices:        []int{2, 0},
+			multipleChoice: true,
+			expected:       []int32{0, 2},
+		},
+		{
+			name:          "Multiple choices in a single choice poll",
+			choices:       []int{0, 1},
+			expectedError: true,
+		},
+		{
+			name:          "No choice",
+			choices:       []int{},
+			expectedError: true,
+		},
+		{
+			name:          "Out of range",
+			choices:       []int{3},
+			expectedError: true,
+		},
+		{
+			name:           "Chosen twice",
+			choices:        []int{1, 1},
+			multipleChoice: true,
+			expectedError:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual, err := ValidateChoices(c.choices, 3, c.multipleChoice)
+			if (err != nil) != c.expectedError {
+				t.Fatalf("expected error: %v, got: %v", c.expectedError, err)
+			}
+
+			if !slices.Equal(actual, c.expected) {
+				t.Errorf("expected %v, got %v", c.expected, actual)
+			}
+		})
+	}
+}
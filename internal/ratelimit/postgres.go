@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/matt-horst/chirpy/internal/database"
+)
+
+// PostgresStore keeps buckets in the rate_limit_buckets table so that every
+// instance sharing the database enforces the same limits.
+type PostgresStore struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db, now: time.Now}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	defer tx.Rollback()
+
+	q := database.New(tx)
+	now := s.now().UTC()
+
+	createParams := database.CreateRateLimitBucketParams{Key: key, Tokens: float64(limit.Burst), UpdatedAt: now}
+	err = q.CreateRateLimitBucket(ctx, createParams)
+	if err != nil {
+		return Result{}, err
+	}
+
+	b, err := q.GetRateLimitBucketForUpdate(ctx, key)
+	if err != nil {
+		return Result{}, err
+	}
+
+	tokens, result := refill(b.Tokens, b.UpdatedAt, now, limit)
+
+	updateParams := database.UpdateRateLimitBucketParams{Key: key, Tokens: tokens, UpdatedAt: now}
+	err = q.UpdateRateLimitBucket(ctx, updateParams)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return result, tx.Commit()
+}
+
+// Cleanup deletes buckets nobody has touched for maxAge.
+func (s *PostgresStore) Cleanup(ctx context.Context, maxAge time.Duration) error {
+	return database.New(s.db).DeleteStaleRateLimitBuckets(ctx, s.now().UTC().Add(-maxAge))
+}
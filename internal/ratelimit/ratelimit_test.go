@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limit := PerMinute(3)
+
+	cases := []struct {
+		name              string
+		key               string
+		after             time.Duration
+		expectAllowed     bool
+		expectedRemaining int
+	}{
+		{
+			name:              "First request",
+			key:               "user",
+			after:             0,
+			expectAllowed:     true,
+			expectedRemaining: 2,
+		},
+		{
+			name:              "Second request",
+			key:               "user",
+			after:             0,
+			expectAllowed:     true,
+			expectedRemaining: 1,
+		},
+		{
+			name:              "Third request",
+			key:               "user",
+			after:             0,
+			expectAllowed:     true,
+			expectedRemaining: 0,
+		},
+		{
+			name:              "Bucket empty",
+			key:               "user",
+			after:             time.Second,
+			expectAllowed:     false,
+			expectedRemaining: 0,
+		},
+		{
+			name:              "Other key",
+			key:               "other",
+			after:             time.Second,
+			expectAllowed:     true,
+			expectedRemaining: 2,
+		},
+		{
+			name:              "Refilled one token",
+			key:               "user",
+			after:             21 * time.Second,
+			expectAllowed:     true,
+			expectedRemaining: 0,
+		},
+		{
+			name:              "Refilled completely",
+			key:               "user",
+			after:             10 * time.Minute,
+			expectAllowed:     true,
+			expectedRemaining: 2,
+		},
+	}
+
+	store := NewMemoryStore()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store.now = func() time.Time { return start.Add(c.after) }
+
+			result, err := store.Take(context.Background(), c.key, limit)
+			if err != nil {
+				t.Fatalf("Take() received error: %v", err)
+			}
+
+			if result.Allowed != c.expectAllowed {
+				t.Errorf("Take() received allowed: %v, expected allowed: %v", result.Allowed, c.expectAllowed)
+			}
+
+			if result.Remaining != c.expectedRemaining {
+				t.Errorf("Take() received remaining: %v, expected remaining: %v", result.Remaining, c.expectedRemaining)
+			}
+
+			if !result.Allowed && result.RetryAfter <= 0 {
+				t.Errorf("Take() received retry after: %v, expected a positive duration", result.RetryAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/matt-horst/chirpy/internal/database"
+)
+
+const notifyChannel = "chirp_events"
+
+// PostgresRelay publishes events with NOTIFY so that the brokers of every
+// instance sharing the database receive them, including this one's.
+type PostgresRelay struct {
+	db     *database.Queries
+	broker *Broker
+}
+
+func NewPostgresRelay(db *sql.DB, broker *Broker) *PostgresRelay {
+	return &PostgresRelay{db: database.New(db), broker: broker}
+}
+
+func (r *PostgresRelay) Publish(ctx context.Context, e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return r.db.NotifyChirpEvent(ctx, string(payload))
+}
+
+// Listen starts passing notifications to the local broker. The listener
+// reconnects by itself after losing its connection; events sent in the
+// meantime are missed by live subscribers, who can resume from the event log.
+func (r *PostgresRelay) Listen(dbURL string) error {
+	listener := pq.NewListener(dbURL, time.Second, time.Minute, func(_ pq.ListenerEventType, err error) {
+		if err != nil {
+			fmt.Printf("Error: stream listener: %v\n", err)
+		}
+	})
+
+	err := listener.Listen(notifyChannel)
+	if err != nil {
+		listener.Close()
+		return err
+	}
+
+	go r.relay(listener)
+
+	return nil
+}
+
+func (r *PostgresRelay) relay(listener *pq.Listener) {
+	ping := time.NewTicker(time.Minute)
+	defer ping.Stop()
+
+	for {
+		select {
+		case n := <-listener.Notify:
+			// A nil notification means the connection was re-established.
+			if n == nil {
+				continue
+			}
+
+			var e Event
+			err := json.Unmarshal([]byte(n.Extra), &e)
+			if err != nil {
+				fmt.Printf("Error: stream listener: %v\n", err)
+				continue
+			}
+
+			r.broker.Publish(context.Background(), e)
+		case <-ping.C:
+			go listener.Ping()
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Package stream fans chirp events out to the clients following them live.
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+type Kind string
+
+const (
+	ChirpCreated Kind = "chirp.created"
+	ChirpDeleted Kind = "chirp.deleted"
+
+	// NotificationCreated tells UserID they have a new notification.
+	NotificationCreated Kind = "notification.created"
+	// MessageCreated tells UserID that AuthorID sent MessageID to one of
+	// their conversations.
+	MessageCreated Kind = "message.created"
+	// Typing tells UserID that AuthorID is typing in ConversationID.
+	Typing Kind = "typing"
+)
+
+// Event is something that happened to a chirp or a user. IDs of chirp events
+// come from the chirp_events table, so they increase and are shared by every
+// instance. Events for a single user aren't logged and have no ID.
+type Event struct {
+	ID       int64     `json:"id,omitempty"`
+	Kind     Kind      `json:"kind"`
+	ChirpID  uuid.UUID `json:"chirp_id"`
+	AuthorID uuid.UUID `json:"author_id"`
+	Hashtags []string  `json:"hashtags,omitempty"`
+	// Unlisted chirps are left out of the public and hashtag streams.
+	Unlisted bool      `json:"unlisted,omitempty"`
+	UserID   uuid.UUID `json:"user_id"`
+
+	ConversationID uuid.UUID `json:"conversation_id"`
+	MessageID      uuid.UUID `json:"message_id"`
+}
+
+// IsChirpEvent reports whether the event is about a chirp rather than for a
+// single user.
+func (e Event) IsChirpEvent() bool {
+	return e.Kind == ChirpCreated || e.Kind == ChirpDeleted
+}
+
+// Filter picks the events a subscriber wants.
+type Filter func(Event) bool
+
+// Publisher sends an event to every subscriber that wants it.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// How many events a subscriber may fall behind before it is disconnected.
+const subscriptionBuffer = 64
+
+type Subscription struct {
+	broker *Broker
+	filter Filter
+	events chan Event
+}
+
+// Events is closed when the subscription is closed or falls too far behind.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscription) Close() {
+	s.broker.remove(s)
+}
+
+// Broker delivers events to the subscribers of this instance.
+type Broker struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: map[*Subscription]struct{}{}}
+}
+
+func (b *Broker) Subscribe(filter Filter) *Subscription {
+	s := &Subscription{broker: b, filter: filter, events: make(chan Event, subscriptionBuffer)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[s] = struct{}{}
+
+	return s
+}
+
+// Publish hands the event to every matching subscriber without waiting on
+// any of them. A subscriber with a full buffer is dropped rather than allowed
+// to hold up the rest; its client can reconnect and resume from the last event
+// it saw.
+func (b *Broker) Publish(ctx context.Context, e Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subs {
+		if s.filter != nil && !s.filter(e) {
+			continue
+		}
+
+		select {
+		case s.events <- e:
+		default:
+			delete(b.subs, s)
+			close(s.events)
+		}
+	}
+
+	return nil
+}
+
+// Subscribers returns how many subscriptions are open.
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+func (b *Broker) remove(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.events)
+	}
+}
@@ -0,0 +1,92 @@
+package stream
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBrokerPublish(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+
+	cases := []struct {
+		name     string
+		filter   Filter
+		events   []Event
+		expected []int64
+	}{
+		{
+			name:     "Everything",
+			filter:   nil,
+			events:   []Event{{ID: 1, AuthorID: alice}, {ID: 2, AuthorID: bob}},
+			expected: []int64{1, 2},
+		},
+		{
+			name:     "By author",
+			filter:   func(e Event) bool { return e.AuthorID == bob },
+			events:   []Event{{ID: 1, AuthorID: alice}, {ID: 2, AuthorID: bob}, {ID: 3, AuthorID: alice}},
+			expected: []int64{2},
+		},
+		{
+			name:   "By kind",
+			filter: func(e Event) bool { return e.Kind == ChirpDeleted },
+			events: []Event{
+				{ID: 1, Kind: ChirpCreated},
+				{ID: 2, Kind: ChirpDeleted},
+				{ID: 3, Kind: ChirpDeleted},
+			},
+			expected: []int64{2, 3},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			broker := NewBroker()
+			sub := broker.Subscribe(c.filter)
+
+			for _, e := range c.events {
+				broker.Publish(context.Background(), e)
+			}
+			sub.Close()
+
+			actual := []int64{}
+			for e := range sub.Events() {
+				actual = append(actual, e.ID)
+			}
+
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("expected %v, got %v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	broker := NewBroker()
+	slow := broker.Subscribe(nil)
+	picky := broker.Subscribe(func(e Event) bool { return false })
+
+	for i := range subscriptionBuffer + 1 {
+		broker.Publish(context.Background(), Event{ID: int64(i + 1)})
+	}
+
+	received := 0
+	for range slow.Events() {
+		received++
+	}
+
+	if received != subscriptionBuffer {
+		t.Errorf("expected %v buffered events, got %v", subscriptionBuffer, received)
+	}
+
+	if broker.Subscribers() != 1 {
+		t.Errorf("expected only the idle subscriber to remain, got %v", broker.Subscribers())
+	}
+
+	// Closing twice must not panic.
+	slow.Close()
+	picky.Close()
+	picky.Close()
+}
@@ -0,0 +1,52 @@
+// Package subscriptions holds the rules for Chirpy Red billing periods.
+package subscriptions
+
+import (
+	"errors"
+	"time"
+)
+
+// Values of subscriptions.status. Active and canceled periods grant Chirpy
+// Red until they end; a canceled one just won't be renewed.
+const (
+	StatusActive     = "active"
+	StatusCanceled   = "canceled"
+	StatusRenewed    = "renewed"
+	StatusDowngraded = "downgraded"
+	StatusExpired    = "expired"
+)
+
+var ErrInvalidPeriod = errors.New("period must end after it starts")
+
+const (
+	DefaultPlan = "chirpy_red"
+	// DefaultPeriod is used when Polka doesn't say when a period ends.
+	DefaultPeriod = 30 * 24 * time.Hour
+)
+
+// IsCurrent reports whether a period with the given status still counts
+// toward Chirpy Red, as long as it hasn't ended.
+func IsCurrent(status string) bool {
+	return status == StatusActive || status == StatusCanceled
+}
+
+// Period works out a billing period from the optional bounds sent by Polka.
+// A missing start defaults to from, which is now for a new subscription or
+// the end of the previous period for a renewal.
+func Period(start, end *time.Time, from time.Time) (time.Time, time.Time, error) {
+	periodStart := from
+	if start != nil {
+		periodStart = *start
+	}
+
+	periodEnd := periodStart.Add(DefaultPeriod)
+	if end != nil {
+		periodEnd = *end
+	}
+
+	if !periodEnd.After(periodStart) {
+		return time.Time{}, time.Time{}, ErrInvalidPeriod
+	}
+
+	return periodStart.UTC(), periodEnd.UTC(), nil
+}
@@ -0,0 +1,79 @@
+package subscriptions
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriod(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	start := from.Add(time.Hour)
+	end := from.Add(7 * 24 * time.Hour)
+	before := from.Add(-time.Hour)
+
+	cases := []struct {
+		name          string
+		start         *time.Time
+		end           *time.Time
+		expectedStart time.Time
+		expectedEnd   time.Time
+		expectedError bool
+	}{
+		{
+			name:          "Defaults",
+			expectedStart: from,
+			expectedEnd:   from.Add(DefaultPeriod),
+		},
+		{
+			name:          "Start only",
+			start:         &start,
+			expectedStart: start,
+			expectedEnd:   start.Add(DefaultPeriod),
+		},
+		{
+			name:          "Both bounds",
+			start:         &start,
+			end:           &end,
+			expectedStart: start,
+			expectedEnd:   end,
+		},
+		{
+			name:          "End before start",
+			end:           &before,
+			expectedError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			periodStart, periodEnd, err := Period(c.start, c.end, from)
+			if (err != nil) != c.expectedError {
+				t.Fatalf("expected error: %v, got: %v", c.expectedError, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !periodStart.Equal(c.expectedStart) || !periodEnd.Equal(c.expectedEnd) {
+				t.Errorf("expected %v to %v, got %v to %v", c.expectedStart, c.expectedEnd, periodStart, periodEnd)
+			}
+		})
+	}
+}
+
+func TestIsCurrent(t *testing.T) {
+	cases := map[string]bool{
+		StatusActive:     true,
+		StatusCanceled:   true,
+		StatusRenewed:    false,
+		StatusDowngraded: false,
+		StatusExpired:    false,
+	}
+
+	for status, expected := range cases {
+		if got := IsCurrent(status); got != expected {
+			t.Errorf("%s: expected %v, got %v", status, expected, got)
+		}
+	}
+}
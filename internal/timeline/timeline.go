@@ -0,0 +1,206 @@
+package timeline
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/database"
+)
+
+// HeavyFollowerCount is the number of followers from which an account's chirps
+// are no longer copied into its followers' timelines. Followers read them from
+// the chirps table instead, so one chirp never turns into millions of writes.
+const HeavyFollowerCount = 10000
+
+// BackfillSize is how many of an account's latest chirps are copied into a
+// timeline when the account is followed or the timeline is rebuilt.
+const BackfillSize = 100
+
+// Fanout copies published chirps into the timelines of their author and the
+// author's followers. The work is queued in fanout_jobs, in the transaction that
+// publishes the chirp, and a pool of background workers drains it.
+type Fanout struct {
+	db   *sql.DB
+	q    *database.Queries
+	wake chan struct{}
+}
+
+// NewFanout starts the workers. They look for jobs when woken by Publish, and
+// every interval for jobs queued by other instances or left over from a
+// restart.
+func NewFanout(db *sql.DB, q *database.Queries, workers int, interval time.Duration) *Fanout {
+	f := &Fanout{db: db, q: q, wake: make(chan struct{}, 1)}
+	for range workers {
+		go f.work(interval)
+	}
+
+	return f
+}
+
+// Queue records that a chirp needs fanning out. Call it in the transaction
+// that publishes the chirp.
+func Queue(ctx context.Context, q *database.Queries, chirpID uuid.UUID) error {
+	return q.CreateFanoutJob(ctx, chirpID)
+}
+
+// Publish wakes the workers once a chirp's job has been committed. It never
+// blocks; a worker that's busy picks the job up when it's done.
+func (f *Fanout) Publish() {
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (f *Fanout) work(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			found, err := f.runJob(context.Background())
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				break
+			}
+
+			if !found {
+				break
+			}
+		}
+
+		select {
+		case <-f.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runJob fans out the next due chirp. Its job stays locked until the
+// transaction commits, and other workers skip locked jobs, so each chirp is
+// fanned out by one worker at a time. A job that fails is retried later, with
+// a growing delay.
+func (f *Fanout) runJob(ctx context.Context) (bool, error) {
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	qtx := f.q.WithTx(tx)
+
+	chirpID, err := qtx.ClaimFanoutJob(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	err = fanOut(ctx, qtx, chirpID)
+	if err == nil {
+		err = qtx.DeleteFanoutJob(ctx, chirpID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		tx.Rollback()
+
+		retryErr := f.q.RetryFanoutJob(ctx, chirpID)
+		if retryErr != nil {
+			fmt.Printf("Error: %v\n", retryErr)
+		}
+
+		return true, fmt.Errorf("fanning out chirp %v: %w", chirpID, err)
+	}
+
+	return true, nil
+}
+
+func fanOut(ctx context.Context, q *database.Queries, chirpID uuid.UUID) error {
+	chirp, err := q.GetSingleChirp(ctx, chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Deleted before we got to it.
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Authors see their own chirps in their timeline, even heavy ones.
+	err = q.AddChirpToOwnTimeline(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+
+	author, err := q.GetUserByID(ctx, chirp.UserID.UUID)
+	if err != nil {
+		return err
+	}
+
+	if author.FollowerCount >= HeavyFollowerCount {
+		return nil
+	}
+
+	_, err = q.FanOutChirp(ctx, chirpID)
+	return err
+}
+
+// Follow copies the latest chirps of a newly followed account into the
+// follower's timeline.
+func Follow(ctx context.Context, q *database.Queries, userID, authorID uuid.UUID) error {
+	params := database.BackfillTimelineFromAuthorParams{
+		UserID:       userID,
+		AuthorID:     authorID,
+		BackfillSize: BackfillSize,
+	}
+	_, err := q.BackfillTimelineFromAuthor(ctx, params)
+	return err
+}
+
+// Unfollow removes an account's chirps from the former follower's timeline.
+// followerCount is the account's count after the unfollow. When it drops below
+// HeavyFollowerCount, the account's latest chirps are queued to be fanned out,
+// since the ones posted while it was heavy only ever reached followers on read.
+func Unfollow(ctx context.Context, q *database.Queries, userID, authorID uuid.UUID, followerCount int32) error {
+	params := database.DeleteTimelineEntriesFromAuthorParams{UserID: userID, AuthorID: authorID}
+	err := q.DeleteTimelineEntriesFromAuthor(ctx, params)
+	if err != nil || followerCount != HeavyFollowerCount-1 {
+		return err
+	}
+
+	backfill := database.CreateAuthorFanoutJobsParams{AuthorID: authorID, BackfillSize: BackfillSize}
+	_, err = q.CreateAuthorFanoutJobs(ctx, backfill)
+	return err
+}
+
+// Rebuild replaces a user's timeline with their own latest chirps and those of
+// every account they follow. Run it inside a transaction so readers never see
+// it empty.
+func Rebuild(ctx context.Context, q *database.Queries, userID uuid.UUID) (int64, error) {
+	err := q.DeleteTimeline(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	own := database.BackfillTimelineFromAuthorParams{
+		UserID:       userID,
+		AuthorID:     userID,
+		BackfillSize: BackfillSize,
+	}
+	ownEntries, err := q.BackfillTimelineFromAuthor(ctx, own)
+	if err != nil {
+		return 0, err
+	}
+
+	params := database.RebuildTimelineParams{
+		BackfillSize:       BackfillSize,
+		UserID:             userID,
+		HeavyFollowerCount: HeavyFollowerCount,
+	}
+	entries, err := q.RebuildTimeline(ctx, params)
+	return ownEntries + entries, err
+}
@@ -0,0 +1,211 @@
+// Package trends ranks hashtags by how quickly their use is growing.
+package trends
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/matt-horst/chirpy/internal/database"
+)
+
+// AllLocales is the key trends across every locale are ranked under.
+const AllLocales = ""
+
+type Config struct {
+	// Window is how far back a chirp counts as recent.
+	Window time.Duration
+	// Baseline is the period before Window that recent use is compared to.
+	Baseline time.Duration
+	// MinAuthors keeps a handful of accounts from pushing a tag into trends.
+	MinAuthors int64
+	// Limit is the number of trends kept per locale.
+	Limit int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Window:     time.Hour,
+		Baseline:   24 * time.Hour,
+		MinAuthors: 3,
+		Limit:      20,
+	}
+}
+
+// Usage counts the chirps using a hashtag among the authors of one locale.
+type Usage struct {
+	Tag      string
+	Locale   string
+	Recent   int64
+	Baseline int64
+	Authors  int64
+}
+
+type Trend struct {
+	Tag      string
+	Recent   int64
+	Baseline int64
+	Authors  int64
+	// Score is how many times more often the tag was used in the window than
+	// its baseline rate predicts.
+	Score float64
+}
+
+// Rank returns the rising hashtags of each locale, plus all of them together
+// under AllLocales, highest score first.
+func Rank(usage []Usage, config Config) map[string][]Trend {
+	byLocale := map[string]map[string]*Trend{AllLocales: {}}
+	add := func(locale string, u Usage) {
+		if byLocale[locale] == nil {
+			byLocale[locale] = map[string]*Trend{}
+		}
+
+		t, ok := byLocale[locale][u.Tag]
+		if !ok {
+			t = &Trend{Tag: u.Tag}
+			byLocale[locale][u.Tag] = t
+		}
+
+		t.Recent += u.Recent
+		t.Baseline += u.Baseline
+		t.Authors += u.Authors
+	}
+
+	for _, u := range usage {
+		add(AllLocales, u)
+		if u.Locale != AllLocales {
+			add(u.Locale, u)
+		}
+	}
+
+	ranked := map[string][]Trend{}
+	for locale, tags := range byLocale {
+		trends := []Trend{}
+		for _, t := range tags {
+			if t.Authors < config.MinAuthors {
+				continue
+			}
+
+			t.Score = score(t.Recent, t.Baseline, config)
+			if t.Score <= 1 {
+				continue
+			}
+
+			trends = append(trends, *t)
+		}
+
+		slices.SortFunc(trends, func(a, b Trend) int {
+			return cmp.Or(
+				cmp.Compare(b.Score, a.Score),
+				cmp.Compare(b.Recent, a.Recent),
+				cmp.Compare(a.Tag, b.Tag),
+			)
+		})
+
+		if len(trends) > config.Limit {
+			trends = trends[:config.Limit]
+		}
+		ranked[locale] = trends
+	}
+
+	return ranked
+}
+
+// score compares recent use to what the baseline rate predicts for a window
+// of the same length. Adding one to both sides keeps brand new tags from
+// scoring infinitely high off a single use.
+func score(recent, baseline int64, config Config) float64 {
+	expected := float64(baseline) * config.Window.Seconds() / config.Baseline.Seconds()
+	return (float64(recent) + 1) / (expected + 1)
+}
+
+// Tracker periodically ranks hashtag use and caches the result.
+type Tracker struct {
+	db     *database.Queries
+	config Config
+
+	mu         sync.RWMutex
+	trends     map[string][]Trend
+	computedAt time.Time
+}
+
+func NewTracker(db *database.Queries, config Config) *Tracker {
+	return &Tracker{db: db, config: config, trends: map[string][]Trend{}}
+}
+
+// Run refreshes the trends right away and then every interval.
+func (t *Tracker) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := t.Refresh(context.Background())
+		if err != nil {
+			fmt.Printf("Error: refreshing trends: %v\n", err)
+		}
+
+		<-ticker.C
+	}
+}
+
+func (t *Tracker) Refresh(ctx context.Context) error {
+	now := time.Now().UTC()
+	windowStart := now.Add(-t.config.Window)
+	params := database.GetHashtagUsageParams{
+		WindowStart:   windowStart,
+		BaselineStart: windowStart.Add(-t.config.Baseline),
+	}
+	rows, err := t.db.GetHashtagUsage(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	usage := []Usage{}
+	for _, row := range rows {
+		usage = append(usage, Usage{
+			Tag:      row.Tag,
+			Locale:   row.Locale,
+			Recent:   row.RecentCount,
+			Baseline: row.BaselineCount,
+			Authors:  row.AuthorCount,
+		})
+	}
+
+	ranked := Rank(usage, t.config)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.trends = ranked
+	t.computedAt = now
+
+	return nil
+}
+
+// Trends returns the cached trends of a locale and when they were computed.
+func (t *Tracker) Trends(locale string) ([]Trend, time.Time) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	trends := t.trends[locale]
+	if trends == nil {
+		trends = []Trend{}
+	}
+
+	return trends, t.computedAt
+}
+
+// Remove drops a tag from the cached trends so a newly denied tag disappears
+// without waiting for the next refresh.
+func (t *Tracker) Remove(tag string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for locale, trends := range t.trends {
+		t.trends[locale] = slices.DeleteFunc(slices.Clone(trends), func(trend Trend) bool {
+			return trend.Tag == tag
+		})
+	}
+}
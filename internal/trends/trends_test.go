@@ -0,0 +1,103 @@
+package trends
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRank(t *testing.T) {
+	config := Config{
+		Window:     time.Hour,
+		Baseline:   24 * time.Hour,
+		MinAuthors: 2,
+		Limit:      2,
+	}
+
+	usage := []Usage{
+		// 48 uses over the baseline is 2 an hour, so 11 recent uses is 4x.
+		{Tag: "golang", Locale: "en", Recent: 11, Baseline: 48, Authors: 5},
+		// Brand new and used 9 times: 10x.
+		{Tag: "launch", Locale: "en", Recent: 9, Baseline: 0, Authors: 3},
+		// Steady use isn't a trend.
+		{Tag: "steady", Locale: "en", Recent: 2, Baseline: 48, Authors: 2},
+		// Rising but only from one account.
+		{Tag: "spam", Locale: "en", Recent: 50, Baseline: 0, Authors: 1},
+		// The French and English uses add up for the global ranking.
+		{Tag: "launch", Locale: "fr", Recent: 10, Baseline: 0, Authors: 2},
+		{Tag: "fromage", Locale: "fr", Recent: 4, Baseline: 0, Authors: 2},
+		// Users without a locale only count globally.
+		{Tag: "golang", Locale: "", Recent: 4, Baseline: 0, Authors: 1},
+	}
+
+	cases := []struct {
+		name     string
+		locale   string
+		expected []Trend
+	}{
+		{
+			name:   "English",
+			locale: "en",
+			expected: []Trend{
+				{Tag: "launch", Recent: 9, Baseline: 0, Authors: 3, Score: 10},
+				{Tag: "golang", Recent: 11, Baseline: 48, Authors: 5, Score: 4},
+			},
+		},
+		{
+			name:   "French",
+			locale: "fr",
+			expected: []Trend{
+				{Tag: "launch", Recent: 10, Baseline: 0, Authors: 2, Score: 11},
+				{Tag: "fromage", Recent: 4, Baseline: 0, Authors: 2, Score: 5},
+			},
+		},
+		{
+			name:   "All locales",
+			locale: AllLocales,
+			expected: []Trend{
+				{Tag: "launch", Recent: 19, Baseline: 0, Authors: 5, Score: 20},
+				{Tag: "golang", Recent: 15, Baseline: 48, Authors: 6, Score: 16.0 / 3},
+			},
+		},
+	}
+
+	ranked := Rank(usage, config)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := ranked[c.locale]
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("expected %+v, got %+v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTrackerRemove(t *testing.T) {
+	tracker := NewTracker(nil, DefaultConfig())
+	tracker.trends = map[string][]Trend{
+		"en":       {{Tag: "a"}, {Tag: "b"}},
+		AllLocales: {{Tag: "b"}, {Tag: "c"}},
+	}
+
+	tracker.Remove("b")
+
+	cases := []struct {
+		name     string
+		locale   string
+		expected []Trend
+	}{
+		{name: "English", locale: "en", expected: []Trend{{Tag: "a"}}},
+		{name: "All locales", locale: AllLocales, expected: []Trend{{Tag: "c"}}},
+		{name: "Unknown locale", locale: "de", expected: []Trend{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual, _ := tracker.Trends(c.locale)
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("expected %+v, got %+v", c.expected, actual)
+			}
+		})
+	}
+}
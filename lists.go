@@ -0,0 +1,442 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+)
+
+const (
+	maxListNameLength        = 50
+	maxListDescriptionLength = 160
+	maxListMembers           = 500
+)
+
+// List is a named set of accounts curated by its owner. Private lists are only
+// visible to the owner; members aren't told they were added.
+type List struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	OwnerID     uuid.UUID `json:"owner_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	IsPrivate   bool      `json:"is_private"`
+}
+
+type ListMember struct {
+	UserID  uuid.UUID `json:"user_id"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+type listRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	IsPrivate   bool   `json:"is_private"`
+}
+
+func (data *listRequest) validate() error {
+	data.Name = strings.TrimSpace(data.Name)
+	if data.Name == "" {
+		return errors.New("list name is required")
+	}
+
+	if utf8.RuneCountInString(data.Name) > maxListNameLength {
+		return errors.New("list name is too long")
+	}
+
+	if utf8.RuneCountInString(data.Description) > maxListDescriptionLength {
+		return errors.New("list description is too long")
+	}
+
+	return nil
+}
+
+func listResponse(list database.List) List {
+	return List{
+		ID:          list.ID,
+		CreatedAt:   list.CreatedAt,
+		UpdatedAt:   list.UpdatedAt,
+		OwnerID:     list.OwnerID,
+		Name:        list.Name,
+		Description: list.Description,
+		IsPrivate:   list.IsPrivate,
+	}
+}
+
+// getVisibleList looks up a list the viewer may see. A private list the
+// viewer doesn't own is reported as missing.
+func (cfg *apiConfig) getVisibleList(ctx context.Context, listID uuid.UUID, viewerID uuid.NullUUID) (database.List, error) {
+	list, err := cfg.dbQueries.GetList(ctx, listID)
+	if err != nil {
+		return database.List{}, err
+	}
+
+	if list.IsPrivate && (!viewerID.Valid || viewerID.UUID != list.OwnerID) {
+		return database.List{}, sql.ErrNoRows
+	}
+
+	return list, nil
+}
+
+// authenticateListOwner checks that the request comes from the owner of the
+// list in the path. It responds with an error and returns false otherwise.
+func (cfg *apiConfig) authenticateListOwner(w http.ResponseWriter, r *http.Request) (uuid.UUID, database.List, bool) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return uuid.UUID{}, database.List{}, false
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return uuid.UUID{}, database.List{}, false
+	}
+
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid list id")
+		return uuid.UUID{}, database.List{}, false
+	}
+
+	list, err := cfg.getVisibleList(r.Context(), listID, uuid.NullUUID{UUID: userID, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "couldn't find list")
+		return uuid.UUID{}, database.List{}, false
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get list")
+		return uuid.UUID{}, database.List{}, false
+	}
+
+	if list.OwnerID != userID {
+		respondWithError(w, http.StatusForbidden, "not the list owner")
+		return uuid.UUID{}, database.List{}, false
+	}
+
+	return userID, list, true
+}
+
+// viewList looks up the list in the path for the requesting viewer, who may
+// be anonymous. It responds with an error and returns false when the list
+// can't be seen.
+func (cfg *apiConfig) viewList(w http.ResponseWriter, r *http.Request) (uuid.NullUUID, database.List, bool) {
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid list id")
+		return uuid.NullUUID{}, database.List{}, false
+	}
+
+	viewerID := cfg.viewerID(r)
+	list, err := cfg.getVisibleList(r.Context(), listID, viewerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "couldn't find list")
+		return uuid.NullUUID{}, database.List{}, false
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get list")
+		return uuid.NullUUID{}, database.List{}, false
+	}
+
+	return viewerID, list, true
+}
+
+func (cfg *apiConfig) createListHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	data := listRequest{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	err = data.validate()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.CreateListParams{
+		OwnerID:     userID,
+		Name:        data.Name,
+		Description: data.Description,
+		IsPrivate:   data.IsPrivate,
+	}
+	list, err := cfg.dbQueries.CreateList(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to create list")
+		return
+	}
+
+	respondWithJson(w, http.StatusCreated, listResponse(list))
+}
+
+// getUserListsHandler lists the lists a user owns. Private lists are only
+// included for the owner.
+func (cfg *apiConfig) getUserListsHandler(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+	params := database.GetListsByOwnerParams{
+		OwnerID:         ownerID,
+		IncludePrivate:  viewerID.Valid && viewerID.UUID == ownerID,
+		BeforeCreatedAt: after.CreatedAt,
+		BeforeID:        after.ID,
+		PageSize:        limit,
+	}
+	lists, err := cfg.dbQueries.GetListsByOwner(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get lists")
+		return
+	}
+
+	resp := Page[List]{Items: []List{}}
+	for _, list := range lists {
+		resp.Items = append(resp.Items, listResponse(list))
+	}
+	if len(lists) > 0 {
+		last := lists[len(lists)-1]
+		resp.NextCursor = nextCursor(len(lists), limit, cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) getListHandler(w http.ResponseWriter, r *http.Request) {
+	_, list, ok := cfg.viewList(w, r)
+	if !ok {
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, listResponse(list))
+}
+
+func (cfg *apiConfig) updateListHandler(w http.ResponseWriter, r *http.Request) {
+	_, list, ok := cfg.authenticateListOwner(w, r)
+	if !ok {
+		return
+	}
+
+	data := listRequest{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	err = data.validate()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.UpdateListParams{
+		ID:          list.ID,
+		Name:        data.Name,
+		Description: data.Description,
+		IsPrivate:   data.IsPrivate,
+	}
+	list, err = cfg.dbQueries.UpdateList(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to update list")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, listResponse(list))
+}
+
+func (cfg *apiConfig) deleteListHandler(w http.ResponseWriter, r *http.Request) {
+	_, list, ok := cfg.authenticateListOwner(w, r)
+	if !ok {
+		return
+	}
+
+	err := cfg.dbQueries.DeleteList(r.Context(), list.ID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to delete list")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) getListMembersHandler(w http.ResponseWriter, r *http.Request) {
+	_, list, ok := cfg.viewList(w, r)
+	if !ok {
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetListMembersParams{
+		ListID:          list.ID,
+		BeforeCreatedAt: after.CreatedAt,
+		BeforeID:        after.ID,
+		PageSize:        limit,
+	}
+	members, err := cfg.dbQueries.GetListMembers(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get list members")
+		return
+	}
+
+	resp := Page[ListMember]{Items: []ListMember{}}
+	for _, member := range members {
+		resp.Items = append(resp.Items, ListMember{UserID: member.UserID, AddedAt: member.CreatedAt})
+	}
+	if len(members) > 0 {
+		last := members[len(members)-1]
+		resp.NextCursor = nextCursor(len(members), limit, cursor{CreatedAt: last.CreatedAt, ID: last.UserID})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) addListMemberHandler(w http.ResponseWriter, r *http.Request) {
+	userID, list, ok := cfg.authenticateListOwner(w, r)
+	if !ok {
+		return
+	}
+
+	memberID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	_, err = cfg.dbQueries.GetUserByID(r.Context(), memberID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "couldn't find user")
+		return
+	}
+
+	blocked, err := cfg.dbQueries.IsBlockedBetween(r.Context(), database.IsBlockedBetweenParams{UserID: userID, OtherUserID: memberID})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to add list member")
+		return
+	}
+
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "can't add this user")
+		return
+	}
+
+	count, err := cfg.dbQueries.CountListMembers(r.Context(), list.ID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to add list member")
+		return
+	}
+
+	if count >= maxListMembers {
+		respondWithError(w, http.StatusBadRequest, "list has too many members")
+		return
+	}
+
+	_, err = cfg.dbQueries.AddListMember(r.Context(), database.AddListMemberParams{ListID: list.ID, UserID: memberID})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to add list member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) removeListMemberHandler(w http.ResponseWriter, r *http.Request) {
+	_, list, ok := cfg.authenticateListOwner(w, r)
+	if !ok {
+		return
+	}
+
+	memberID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	_, err = cfg.dbQueries.RemoveListMember(r.Context(), database.RemoveListMemberParams{ListID: list.ID, UserID: memberID})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to remove list member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getListTimelineHandler pages through the chirps of a list's members with
+// the same visibility and mute rules as the home timeline, applied for the
+// viewer rather than the list owner.
+func (cfg *apiConfig) getListTimelineHandler(w http.ResponseWriter, r *http.Request) {
+	viewerID, list, ok := cfg.viewList(w, r)
+	if !ok {
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetListTimelineParams{
+		ListID:          list.ID,
+		BeforeCreatedAt: after.CreatedAt,
+		BeforeID:        after.ID,
+		ViewerID:        viewerID,
+		PageSize:        limit,
+	}
+	chirps, err := cfg.dbQueries.GetListTimeline(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get list timeline")
+		return
+	}
+
+	cfg.respondWithChirpPage(w, r, chirps, limit)
+}
@@ -1,30 +1,58 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"os/signal"
 	"slices"
 	"sort"
 	"strings"
 	"sync/atomic"
+	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	"github.com/matt-horst/chirpy/internal/auth"
 	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/media"
+	"github.com/matt-horst/chirpy/internal/moderation"
+	"github.com/matt-horst/chirpy/internal/pins"
+	"github.com/matt-horst/chirpy/internal/polka"
+	"github.com/matt-horst/chirpy/internal/ratelimit"
+	"github.com/matt-horst/chirpy/internal/stream"
+	"github.com/matt-horst/chirpy/internal/subscriptions"
+	"github.com/matt-horst/chirpy/internal/timeline"
+	"github.com/matt-horst/chirpy/internal/trends"
 )
 
 type apiConfig struct {
 	fileServerHits atomic.Int32
+	db *sql.DB
 	dbQueries *database.Queries
 	platform string
 	secret string
 	polkaKey string
+	polkaSecret string
+	moderation *moderation.Pipeline
+	rateLimiter ratelimit.Store
+	fanout *timeline.Fanout
+	blobs media.BlobStore
+	trends *trends.Tracker
+	broker *stream.Broker
+	events stream.Publisher
+	streams *streamCounter
+	redCache *redCache
+	sockets *wsRegistry
+	wsOrigins []string
 }
 
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
@@ -98,10 +126,28 @@ func respondWithError(w http.ResponseWriter, code int, msg string) {
 		w.Write(resp)
 }
 
+// viewerID returns the ID of the user making the request when it carries a
+// valid access token. Endpoints that don't require authentication use it to
+// tailor what the viewer sees.
+func (cfg *apiConfig) viewerID(r *http.Request) uuid.NullUUID {
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.secret)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+
+	return uuid.NullUUID{UUID: userID, Valid: true}
+}
+
 func (cfg *apiConfig) createUserHandler(w http.ResponseWriter, r *http.Request) {
 	data := struct {
 		Password string `json:"password"`
 		Email string 	`json:"email"`
+		Handle string 	`json:"handle"`
 	} {}
 
 	decoder := json.NewDecoder(r.Body)
@@ -112,6 +158,27 @@ func (cfg *apiConfig) createUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	handle := trimHandle(data.Handle)
+	if handle == "" {
+		handle = defaultHandle()
+	} else {
+		err = validateHandle(handle)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err = checkHandleAvailable(r.Context(), cfg.dbQueries, handle, uuid.NullUUID{})
+		if errors.Is(err, errHandleTaken) {
+			respondWithError(w, http.StatusConflict, err.Error())
+			return
+		} else if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			w.WriteHeader(500)
+			return
+		}
+	}
+
 	hashed_password, err := auth.HashPassword(data.Password)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
@@ -119,9 +186,12 @@ func (cfg *apiConfig) createUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	params := database.CreateUserParams {Email: data.Email, HashedPassword: hashed_password}
+	params := database.CreateUserParams {Email: data.Email, HashedPassword: hashed_password, Handle: handle}
 	dbUser, err := cfg.dbQueries.CreateUser(r.Context(), params)
-	if err != nil {
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "email or handle is already taken")
+		return
+	} else if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		w.WriteHeader(500)
 		return
@@ -132,6 +202,7 @@ func (cfg *apiConfig) createUserHandler(w http.ResponseWriter, r *http.Request)
 		CreatedAt: dbUser.CreatedAt, 
 		UpdatedAt: dbUser.UpdatedAt, 
 		Email: dbUser.Email, 
+		Handle: dbUser.Handle,
 		IsChirpyRed: dbUser.IsChirpyRed,
 	}
 
@@ -164,6 +235,11 @@ func (cfg *apiConfig) loginUserHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 
+	if ok && isSuspended(user) {
+		respondWithError(w, http.StatusForbidden, "account is suspended")
+		return
+	}
+
 	if ok {
 		token, err := auth.MakeJWT(user.ID, cfg.secret, time.Hour)
 		if err != nil {
@@ -192,6 +268,7 @@ func (cfg *apiConfig) loginUserHandler(w http.ResponseWriter, r *http.Request) {
 			CreatedAt: user.CreatedAt,
 			UpdatedAt: user.UpdatedAt,
 			Email: user.Email,
+			Handle: user.Handle,
 			Token: token,
 			RefreshToken: refreshToken,
 			IsChirpyRed: user.IsChirpyRed,
@@ -205,6 +282,9 @@ func (cfg *apiConfig) loginUserHandler(w http.ResponseWriter, r *http.Request) {
 func (cfg *apiConfig) createChirpHandler(w http.ResponseWriter, r *http.Request) {
 	chirp := struct {
 		Body string 		`json:"body"`
+		MediaIDs []uuid.UUID `json:"media_ids"`
+		Visibility string	`json:"visibility"`
+		Poll *pollRequest	`json:"poll"`
 	} {}
 
 
@@ -228,36 +308,114 @@ func (cfg *apiConfig) createChirpHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if len(chirp.Body) > 140 {
+	if utf8.RuneCountInString(chirp.Body) > maxChirpLength {
 		respondWithError(w, 400, "Chirp is too long")
 		return
 	}
 
-	profanity := []string{"kerfuffle", "sharbert", "fornax"}
-	words := strings.Split(chirp.Body, " ")
-	for i, word := range words {
-		if slices.Contains(profanity[:], strings.ToLower(word)) {
-			words[i] = "****"
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't find user")
+		return
+	}
+
+	if isSuspended(user) {
+		respondWithError(w, http.StatusForbidden, "account is suspended")
+		return
+	}
+
+	if chirp.Visibility == "" {
+		chirp.Visibility = visibilityPublic
+	} else if !slices.Contains(chirpVisibilities, chirp.Visibility) {
+		respondWithError(w, 400, "invalid visibility")
+		return
+	}
+
+	if len(chirp.MediaIDs) > maxChirpMedia {
+		respondWithError(w, 400, "Chirp has too many media attachments")
+		return
+	}
+
+	if chirp.Poll != nil {
+		err = chirp.Poll.validate()
+		if err != nil {
+			respondWithError(w, 400, err.Error())
+			return
 		}
 	}
 
+	mediaFiles, err := cfg.lookupOwnMedia(r.Context(), userID, chirp.MediaIDs)
+	if errors.Is(err, errInvalidMedia) {
+		respondWithError(w, 400, err.Error())
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		w.WriteHeader(500)
+		return
+	}
+
+	result := cfg.moderation.Run(chirp.Body)
+	if result.Action == moderation.Reject {
+		cfg.recordModerationDecisions(r.Context(), userID, uuid.NullUUID{}, chirp.Body, result)
+		respondWithError(w, http.StatusBadRequest, "Chirp was rejected by moderation")
+		return
+	}
+
 	params := database.CreateChirpParams {
-		Body: strings.Join(words, " "),
+		Body: result.Body,
 		UserID: uuid.NullUUID { Valid: true, UUID: userID },
+		ModerationStatus: moderationStatus(result),
+		Visibility: chirp.Visibility,
+	}
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		w.WriteHeader(500)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	dbChirp, err := qtx.CreateChirp(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		w.WriteHeader(500)
+		return
 	}
-	dbChirp, err := cfg.dbQueries.CreateChirp(r.Context(), params)
+
+	var published publishedChirp
+	err = attachChirpMedia(r.Context(), qtx, dbChirp.ID, mediaFiles)
+	if err == nil && chirp.Poll != nil {
+		err = createPoll(r.Context(), qtx, dbChirp.ID, *chirp.Poll)
+	}
+	if err == nil {
+		published, err = preparePublication(r.Context(), qtx, dbChirp, user)
+	}
+
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		w.WriteHeader(500)
+		return
+	}
+
+	cfg.announceChirp(published)
+	cfg.recordModerationDecisions(r.Context(), userID, uuid.NullUUID{UUID: dbChirp.ID, Valid: true}, chirp.Body, result)
+
+	resp, err := cfg.chirpResponse(r.Context(), dbChirp, uuid.NullUUID{UUID: userID, Valid: true})
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		w.WriteHeader(500)
 		return
 	}
+	resp.Media = mediaResponses(mediaFiles)
 
-	resp := Chirp {
-		ID: dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		Body: dbChirp.Body,
-		UserID: dbChirp.UserID.UUID,
+	if dbChirp.ModerationStatus == chirpPendingReview {
+		respondWithJson(w, http.StatusAccepted, resp)
+		return
 	}
 
 	respondWithJson(w, 201, resp)
@@ -266,45 +424,85 @@ func (cfg *apiConfig) createChirpHandler(w http.ResponseWriter, r *http.Request)
 func (cfg *apiConfig) getAllChirpsHandler(w http.ResponseWriter, r *http.Request) {
 	authorID := r.URL.Query().Get("author_id")
 	sortBy := r.URL.Query().Get("sort")
+	viewerID := cfg.viewerID(r)
 
 	var chirps []database.Chirp
+	var pinned []database.Chirp
 	var err error
 
 	if authorID == "" {
-		chirps, err = cfg.dbQueries.GetAllChirps(r.Context())
+		chirps, err = cfg.dbQueries.GetAllChirps(r.Context(), viewerID)
 		if err != nil {
 			w.WriteHeader(500)
 			fmt.Printf("Error: %v\n", err)
 			return
 		}
 	} else {
-		authorID, err := uuid.Parse(authorID)
-		chirps, err = cfg.dbQueries.GetAllChirpsByAuthor(r.Context(), uuid.NullUUID{ UUID: authorID, Valid: true })
+		author, _, err := cfg.resolveUser(r.Context(), authorID)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "couldn't find author")
+			return
+		} else if err != nil {
+			w.WriteHeader(500)
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		params := database.GetAllChirpsByAuthorParams{
+			AuthorID: uuid.NullUUID{ UUID: author.ID, Valid: true },
+			ViewerID: viewerID,
+		}
+		chirps, err = cfg.dbQueries.GetAllChirpsByAuthor(r.Context(), params)
 		if err != nil {
 			w.WriteHeader(500)
 			fmt.Printf("Error: %v\n", err)
 			return
 		}
-	}
 
+		pinnedParams := database.GetPinnedChirpsParams{
+			UserID: author.ID,
+			ViewerID: viewerID,
+			PageSize: int32(pins.Limit(author.IsChirpyRed)),
+		}
+		pinned, err = cfg.dbQueries.GetPinnedChirps(r.Context(), pinnedParams)
+		if err != nil {
+			w.WriteHeader(500)
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
 
-	var resp []Chirp
-	for _, chirp := range chirps {
-		resp = append(resp, Chirp{
-			ID: chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body: chirp.Body,
-			UserID: chirp.UserID.UUID,
+		// Pinned chirps come first, so leave them out of the rest.
+		chirps = slices.DeleteFunc(chirps, func(chirp database.Chirp) bool {
+			return slices.ContainsFunc(pinned, func(pin database.Chirp) bool { return pin.ID == chirp.ID })
 		})
 	}
 
+
+	resp, err := cfg.chirpResponses(r.Context(), chirps, viewerID)
+	if err != nil {
+		w.WriteHeader(500)
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
 	if sortBy == "desc" {
 		sort.Slice(resp[:], func(i, j int) bool {
 			return resp[i].CreatedAt.After(resp[j].CreatedAt)
 		})
 	}
 
+	pinnedResp, err := cfg.chirpResponses(r.Context(), pinned, viewerID)
+	if err != nil {
+		w.WriteHeader(500)
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	for i := range pinnedResp {
+		pinnedResp[i].Pinned = true
+	}
+	resp = append(pinnedResp, resp...)
+
 	respondWithJson(w, 200, resp)
 }
 
@@ -322,13 +520,32 @@ func (cfg *apiConfig) getSingleChirpHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	resp := Chirp {
-		ID: chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body: chirp.Body,
-		UserID: chirp.UserID.UUID,
+	visible, err := cfg.canViewChirp(r.Context(), chirp, cfg.viewerID(r))
+	if err != nil {
+		w.WriteHeader(500)
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	if !visible {
+		w.WriteHeader(404)
+		return
+	}
+
+	mediaFiles, err := cfg.dbQueries.GetChirpMedia(r.Context(), chirp.ID)
+	if err != nil {
+		w.WriteHeader(500)
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	resp, err := cfg.chirpResponse(r.Context(), chirp, cfg.viewerID(r))
+	if err != nil {
+		w.WriteHeader(500)
+		fmt.Printf("Error: %v\n", err)
+		return
 	}
+	resp.Media = mediaResponses(mediaFiles)
 
 	respondWithJson(w, 200, resp)
 }
@@ -357,6 +574,17 @@ func (cfg *apiConfig) refreshHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), refreshToken.UserID.UUID)
+	if err != nil {
+		respondWithError(w, 401, "no user found")
+		return
+	}
+
+	if isSuspended(user) {
+		respondWithError(w, http.StatusForbidden, "account is suspended")
+		return
+	}
+
 	accessToken, err := auth.MakeJWT(refreshToken.UserID.UUID, cfg.secret, time.Hour)
 	if err != nil {
 		w.WriteHeader(500)
@@ -462,7 +690,8 @@ func (cfg *apiConfig) deleteChirpHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	chirp, err := cfg.dbQueries.GetSingleChirp(r.Context(), chirpID)
-	if err != nil {
+	if err != nil || chirp.Status != chirpPublished {
+		// Drafts are deleted through /api/drafts.
 		respondWithError(w, http.StatusNotFound, "no chirp found")
 		return
 	}
@@ -472,11 +701,24 @@ func (cfg *apiConfig) deleteChirpHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	_, err = cfg.dbQueries.DeleteChirp(r.Context(), chirpID)
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "failed to delete chirp")
 		return
 	}
+	defer tx.Rollback()
+
+	event, err := deleteChirp(r.Context(), cfg.dbQueries.WithTx(tx), chirp)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to delete chirp")
+		return
+	}
+
+	cfg.publishChirpEvent(event)
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -485,30 +727,46 @@ func (cfg *apiConfig) polkaWebhooksHandler(w http.ResponseWriter, r *http.Reques
 	apiKey, err := auth.GetAPIKey(r.Header)
 	if err != nil || apiKey != cfg.polkaKey {
 		respondWithError(w, http.StatusUnauthorized, "invalid api key")
+		return
 	}
 
-	data := struct {
-		Event string 		`json:"event"`
-		Data struct {
-			UserID uuid.UUID 		`json:"user_id"`
-		}					`json:"data"`
-	} {}
-
-	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&data)
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPolkaPayload))
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "invalid payload")
 		return
 	}
 
-	if data.Event != "user.upgraded" {
-		w.WriteHeader(http.StatusNoContent)
+	err = polka.Verify(r.Header.Get(polka.SignatureHeader), body, cfg.polkaSecret, time.Now(), polka.DefaultTolerance)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
 
-	_, err = cfg.dbQueries.UpgradeUserToChirpyRed(r.Context(), data.Data.UserID)
-	if err != nil {
-		respondWithError(w, http.StatusNotFound, "couldn't find user")
+	data := polka.Event{}
+	err = json.Unmarshal(body, &data)
+	if err != nil || data.EventID == "" {
+		respondWithError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+
+	// A redelivered event is already stored, and is only applied again if it
+	// failed last time.
+	_, err = cfg.dbQueries.CreatePolkaEvent(r.Context(), database.CreatePolkaEventParams{EventID: data.EventID, Event: data.Event, Payload: body})
+	if err == nil {
+		err = cfg.processPolkaEvent(r.Context(), data.EventID)
+	}
+	if errors.Is(err, errPolkaEventProcessed) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	} else if errors.Is(err, errUnknownPolkaUser) {
+		respondWithError(w, http.StatusNotFound, err.Error())
+		return
+	} else if errors.Is(err, subscriptions.ErrInvalidPeriod) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to process event")
 		return
 	}
 
@@ -520,6 +778,7 @@ type User struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Email string 		`json:"email"`
+	Handle string 		`json:"handle"`
 	Token string 		`json:"token"`
 	RefreshToken string `json:"refresh_token"`
 	IsChirpyRed bool	`json:"is_chirpy_red"`
@@ -531,6 +790,12 @@ type Chirp struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	Body string			`json:"body"`
 	UserID uuid.UUID 	`json:"user_id"`
+	Entities []Entity	`json:"entities"`
+	Media []Media		`json:"media,omitempty"`
+	Visibility string	`json:"visibility"`
+	Poll *Poll			`json:"poll,omitempty"`
+	// Pinned is only set when listing an author's chirps.
+	Pinned bool			`json:"pinned,omitempty"`
 }
 
 
@@ -545,19 +810,108 @@ func main() {
 	}
 	dbQueries := database.New(db)
 
+	moderationConfig := moderation.DefaultConfig()
+	if path := os.Getenv("MODERATION_CONFIG"); path != "" {
+		moderationConfig, err = moderation.LoadConfig(path)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+	}
+
+	moderationPipeline, err := moderationConfig.Pipeline()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	var rateLimiter ratelimit.Store
+	switch os.Getenv("RATE_LIMIT_BACKEND") {
+	case "", "memory":
+		rateLimiter = ratelimit.NewMemoryStore()
+	case "postgres":
+		store := ratelimit.NewPostgresStore(db)
+		go cleanupRateLimits(store, 10*time.Minute)
+		rateLimiter = store
+	default:
+		fmt.Printf("Error: unknown rate limit backend %v\n", os.Getenv("RATE_LIMIT_BACKEND"))
+		return
+	}
+
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "media"
+	}
+
+	blobs, err := media.NewLocalStore(mediaDir)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	broker := stream.NewBroker()
+	var events stream.Publisher
+	switch os.Getenv("STREAM_BACKEND") {
+	case "", "memory":
+		events = broker
+	case "postgres":
+		relay := stream.NewPostgresRelay(db, broker)
+		err = relay.Listen(dbURL)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		events = relay
+	default:
+		fmt.Printf("Error: unknown stream backend %v\n", os.Getenv("STREAM_BACKEND"))
+		return
+	}
+	polkaSecret := os.Getenv("POLKA_WEBHOOK_SECRET")
+	if polkaSecret == "" {
+		fmt.Printf("Error: POLKA_WEBHOOK_SECRET must be set\n")
+		return
+	}
+
+	var wsOrigins []string
+	for _, origin := range strings.Split(os.Getenv("WS_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			wsOrigins = append(wsOrigins, origin)
+		}
+	}
+
+	go cleanupChirpEvents(dbQueries, time.Hour)
+	go cleanupWSTickets(dbQueries, 10*time.Minute)
+	go purgeDeletedChirps(dbQueries, time.Hour)
+
 	mux := http.NewServeMux()
 
 	apiConfig := apiConfig {
+		db: db,
 		dbQueries: dbQueries,
 		platform: os.Getenv("PLATFORM"),
 		secret: os.Getenv("SECRET"),
 		polkaKey: os.Getenv("POLKA_KEY"),
+		polkaSecret: polkaSecret,
+		moderation: moderationPipeline,
+		rateLimiter: rateLimiter,
+		fanout: timeline.NewFanout(db, dbQueries, 4, 10*time.Second),
+		blobs: blobs,
+		trends: newTrendTracker(dbQueries),
+		broker: broker,
+		events: events,
+		streams: newStreamCounter(),
+		redCache: newRedCache(),
+		sockets: newWSRegistry(),
+		wsOrigins: wsOrigins,
 	}
+	go apiConfig.publishScheduledChirps(scheduledChirpInterval)
+	go apiConfig.expireSubscriptions(subscriptionExpiryInterval)
 
 	var fileSystem http.Dir = "."
 	fileServer := http.FileServer(fileSystem)
 	fileServer = http.StripPrefix("/app", fileServer)
 	mux.Handle("/app/", apiConfig.middlewareMetricsInc(fileServer))
+	mux.HandleFunc("GET /media/{key}", apiConfig.serveMediaHandler)
 
 	mux.HandleFunc("GET /api/healthz", healthzHandler)
 	mux.HandleFunc("GET /admin/metrics", apiConfig.metricsHandler)
@@ -571,12 +925,111 @@ func main() {
 	mux.HandleFunc("POST /api/revoke", apiConfig.revokeHandler)
 	mux.HandleFunc("PUT /api/users", apiConfig.updateUserHandler)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiConfig.deleteChirpHandler)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/restore", apiConfig.restoreChirpHandler)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", apiConfig.updateChirpHandler)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}/visibility", apiConfig.updateChirpVisibilityHandler)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/votes", apiConfig.votePollHandler)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", apiConfig.bookmarkChirpHandler)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", apiConfig.unbookmarkChirpHandler)
+	mux.HandleFunc("GET /api/bookmarks", apiConfig.getBookmarksHandler)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/pin", apiConfig.pinChirpHandler)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/pin", apiConfig.unpinChirpHandler)
+	mux.HandleFunc("PUT /api/pins", apiConfig.reorderPinsHandler)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/revisions", apiConfig.getChirpRevisionsHandler)
+	mux.HandleFunc("POST /api/drafts", apiConfig.createDraftHandler)
+	mux.HandleFunc("GET /api/drafts", apiConfig.getDraftsHandler)
+	mux.HandleFunc("GET /api/drafts/{chirpID}", apiConfig.getDraftHandler)
+	mux.HandleFunc("PUT /api/drafts/{chirpID}", apiConfig.updateDraftHandler)
+	mux.HandleFunc("DELETE /api/drafts/{chirpID}", apiConfig.deleteDraftHandler)
+	mux.HandleFunc("POST /api/drafts/{chirpID}/publish", apiConfig.publishDraftHandler)
 	mux.HandleFunc("POST /api/polka/webhooks", apiConfig.polkaWebhooksHandler)
-
-	server := http.Server {Addr: ":8080", Handler: mux}
+	mux.HandleFunc("GET /api/subscriptions", apiConfig.getSubscriptionsHandler)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/reports", apiConfig.createChirpReportHandler)
+	mux.HandleFunc("GET /api/reports", apiConfig.getMyReportsHandler)
+	mux.HandleFunc("GET /admin/reports", apiConfig.getReportQueueHandler)
+	mux.HandleFunc("POST /admin/reports/{chirpID}", apiConfig.moderateChirpHandler)
+	mux.HandleFunc("POST /admin/users/{userID}/suspension", apiConfig.suspendUserHandler)
+	mux.HandleFunc("DELETE /admin/users/{userID}/suspension", apiConfig.liftSuspensionHandler)
+	mux.HandleFunc("POST /admin/users/{userID}/shadowban", apiConfig.shadowbanUserHandler)
+	mux.HandleFunc("DELETE /admin/users/{userID}/shadowban", apiConfig.liftShadowbanHandler)
+	mux.HandleFunc("POST /api/appeals", apiConfig.createAppealHandler)
+	mux.HandleFunc("GET /admin/appeals", apiConfig.getAppealsHandler)
+	mux.HandleFunc("POST /admin/appeals/{appealID}", apiConfig.resolveAppealHandler)
+	mux.HandleFunc("GET /api/users/{handle}", apiConfig.getProfileHandler)
+	mux.HandleFunc("PUT /api/users/profile", apiConfig.updateProfileHandler)
+	mux.HandleFunc("POST /api/users/{userID}/follow", apiConfig.followUserHandler)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", apiConfig.unfollowUserHandler)
+	mux.HandleFunc("GET /api/users/{userID}/followers", apiConfig.getFollowersHandler)
+	mux.HandleFunc("GET /api/users/{userID}/following", apiConfig.getFollowingHandler)
+	mux.HandleFunc("GET /api/timeline", apiConfig.getTimelineHandler)
+	mux.HandleFunc("GET /api/follow_requests", apiConfig.getFollowRequestsHandler)
+	mux.HandleFunc("POST /api/follow_requests/{userID}/approve", apiConfig.approveFollowRequestHandler)
+	mux.HandleFunc("POST /api/follow_requests/{userID}/deny", apiConfig.denyFollowRequestHandler)
+	mux.HandleFunc("POST /admin/users/{userID}/timeline", apiConfig.rebuildTimelineHandler)
+	mux.HandleFunc("POST /api/users/{userID}/block", apiConfig.blockUserHandler)
+	mux.HandleFunc("DELETE /api/users/{userID}/block", apiConfig.unblockUserHandler)
+	mux.HandleFunc("POST /api/users/{userID}/mute", apiConfig.muteUserHandler)
+	mux.HandleFunc("DELETE /api/users/{userID}/mute", apiConfig.unmuteUserHandler)
+	mux.HandleFunc("GET /api/blocks", apiConfig.getBlockedUsersHandler)
+	mux.HandleFunc("GET /api/mutes", apiConfig.getMutedUsersHandler)
+	mux.HandleFunc("POST /api/media", apiConfig.uploadMediaHandler)
+	mux.HandleFunc("GET /api/hashtags/{tag}", apiConfig.getHashtagChirpsHandler)
+	mux.HandleFunc("GET /api/mentions", apiConfig.getMentionsHandler)
+	mux.HandleFunc("GET /api/trends", apiConfig.getTrendsHandler)
+	mux.HandleFunc("GET /admin/trends/denylist", apiConfig.getTrendDenylistHandler)
+	mux.HandleFunc("POST /admin/trends/denylist", apiConfig.denyTrendHandler)
+	mux.HandleFunc("DELETE /admin/trends/denylist/{tag}", apiConfig.allowTrendHandler)
+	mux.HandleFunc("GET /admin/polka/events", apiConfig.getFailedPolkaEventsHandler)
+	mux.HandleFunc("POST /admin/polka/events/{eventID}/reprocess", apiConfig.reprocessPolkaEventHandler)
+	mux.HandleFunc("GET /api/notifications", apiConfig.getNotificationsHandler)
+	mux.HandleFunc("GET /api/notifications/unread_count", apiConfig.getUnreadNotificationCountHandler)
+	mux.HandleFunc("POST /api/notifications/read", apiConfig.markNotificationsReadHandler)
+	mux.HandleFunc("GET /api/notifications/preferences", apiConfig.getNotificationPreferencesHandler)
+	mux.HandleFunc("PUT /api/notifications/preferences", apiConfig.updateNotificationPreferencesHandler)
+	mux.HandleFunc("GET /api/stream", apiConfig.streamHandler)
+	mux.HandleFunc("GET /api/ws", apiConfig.wsHandler)
+	mux.HandleFunc("POST /api/ws/tickets", apiConfig.createWSTicketHandler)
+	mux.HandleFunc("POST /api/conversations", apiConfig.createConversationHandler)
+	mux.HandleFunc("GET /api/conversations", apiConfig.getConversationsHandler)
+	mux.HandleFunc("GET /api/conversations/{conversationID}/messages", apiConfig.getMessagesHandler)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/messages", apiConfig.sendMessageHandler)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/read", apiConfig.markConversationReadHandler)
+	mux.HandleFunc("POST /api/lists", apiConfig.createListHandler)
+	mux.HandleFunc("GET /api/users/{userID}/lists", apiConfig.getUserListsHandler)
+	mux.HandleFunc("GET /api/lists/{listID}", apiConfig.getListHandler)
+	mux.HandleFunc("PUT /api/lists/{listID}", apiConfig.updateListHandler)
+	mux.HandleFunc("DELETE /api/lists/{listID}", apiConfig.deleteListHandler)
+	mux.HandleFunc("GET /api/lists/{listID}/members", apiConfig.getListMembersHandler)
+	mux.HandleFunc("POST /api/lists/{listID}/members/{userID}", apiConfig.addListMemberHandler)
+	mux.HandleFunc("DELETE /api/lists/{listID}/members/{userID}", apiConfig.removeListMemberHandler)
+	mux.HandleFunc("GET /api/lists/{listID}/timeline", apiConfig.getListTimelineHandler)
+
+	server := http.Server {Addr: ":8080", Handler: apiConfig.middlewareRateLimit(mux)}
+	server.RegisterOnShutdown(apiConfig.sockets.shutdown)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdown := make(chan struct{})
+	go func() {
+		defer close(shutdown)
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		// Event streams never go idle, so close whatever is left at the deadline.
+		err := server.Shutdown(shutdownCtx)
+		if err != nil {
+			server.Close()
+		}
+	}()
 
 	err = server.ListenAndServe()
-	if err != nil {
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		fmt.Printf("Error: %v", err)
+		return
 	}
+
+	<-shutdown
 }
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/media"
+)
+
+const (
+	maxMediaUploadSize = 10 << 20
+	maxChirpMedia      = 4
+)
+
+var errInvalidMedia = errors.New("invalid media id")
+
+type Media struct {
+	ID           uuid.UUID `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	ContentType  string    `json:"content_type"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+}
+
+func mediaURL(key string) string {
+	return "/media/" + key
+}
+
+func mediaResponse(file database.MediaFile) Media {
+	return Media{
+		ID:           file.ID,
+		CreatedAt:    file.CreatedAt,
+		ContentType:  file.ContentType,
+		Width:        file.Width,
+		Height:       file.Height,
+		URL:          mediaURL(file.BlobKey),
+		ThumbnailURL: mediaURL(file.ThumbnailKey),
+	}
+}
+
+func mediaResponses(files []database.MediaFile) []Media {
+	resp := []Media{}
+	for _, file := range files {
+		resp = append(resp, mediaResponse(file))
+	}
+	return resp
+}
+
+// lookupOwnMedia fetches media a user wants to use, failing with
+// errInvalidMedia when any of it is missing, repeated or someone else's.
+func (cfg *apiConfig) lookupOwnMedia(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]database.MediaFile, error) {
+	files := []database.MediaFile{}
+	seen := map[uuid.UUID]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			return nil, errInvalidMedia
+		}
+		seen[id] = true
+
+		file, err := cfg.dbQueries.GetMediaFile(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && file.UserID != userID) {
+			return nil, errInvalidMedia
+		} else if err != nil {
+			return nil, err
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+func attachChirpMedia(ctx context.Context, q *database.Queries, chirpID uuid.UUID, files []database.MediaFile) error {
+	for i, file := range files {
+		params := database.AttachChirpMediaParams{
+			ChirpID:  chirpID,
+			MediaID:  file.ID,
+			Position: int32(i),
+		}
+		err := q.AttachChirpMedia(ctx, params)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cfg *apiConfig) uploadMediaHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "missing access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't find user")
+		return
+	}
+
+	if isSuspended(user) {
+		respondWithError(w, http.StatusForbidden, "account is suspended")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxMediaUploadSize)
+	file, _, err := r.FormFile("file")
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "file is too large")
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusBadRequest, "missing file")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to read file")
+		return
+	}
+
+	// Trust the bytes rather than whatever content type the client sent.
+	if _, ok := media.Extensions[http.DetectContentType(data)]; !ok {
+		respondWithError(w, http.StatusUnsupportedMediaType, "file must be a JPEG, PNG or GIF image")
+		return
+	}
+
+	original, thumbnail, err := media.Process(data)
+	if errors.Is(err, media.ErrUnsupportedType) {
+		respondWithError(w, http.StatusUnsupportedMediaType, "file must be a JPEG, PNG or GIF image")
+		return
+	} else if errors.Is(err, media.ErrTooLarge) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "image dimensions are too large")
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid image")
+		return
+	}
+
+	id := uuid.New()
+	blobKey := id.String() + media.Extensions[original.ContentType]
+	thumbnailKey := id.String() + "_thumb.jpg"
+
+	err = cfg.blobs.Put(r.Context(), blobKey, bytes.NewReader(original.Data))
+	if err == nil {
+		err = cfg.blobs.Put(r.Context(), thumbnailKey, bytes.NewReader(thumbnail.Data))
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		cfg.deleteBlobs(blobKey, thumbnailKey)
+		respondWithError(w, http.StatusInternalServerError, "failed to store media")
+		return
+	}
+
+	params := database.CreateMediaFileParams{
+		ID:           id,
+		UserID:       userID,
+		ContentType:  original.ContentType,
+		Width:        int32(original.Width),
+		Height:       int32(original.Height),
+		SizeBytes:    int32(len(original.Data)),
+		BlobKey:      blobKey,
+		ThumbnailKey: thumbnailKey,
+	}
+	mediaFile, err := cfg.dbQueries.CreateMediaFile(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		cfg.deleteBlobs(blobKey, thumbnailKey)
+		respondWithError(w, http.StatusInternalServerError, "failed to store media")
+		return
+	}
+
+	respondWithJson(w, http.StatusCreated, mediaResponse(mediaFile))
+}
+
+func (cfg *apiConfig) deleteBlobs(keys ...string) {
+	for _, key := range keys {
+		err := cfg.blobs.Delete(context.Background(), key)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+}
+
+// serveMediaHandler serves stored blobs. A key is never reused for different
+// content, so clients may cache responses forever.
+func (cfg *apiConfig) serveMediaHandler(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	if !media.ValidKey(key) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	etag := `"` + key + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	blob, err := cfg.blobs.Open(r.Context(), key)
+	if errors.Is(err, media.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", mime.TypeByExtension(path.Ext(key)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	_, err = io.Copy(w, blob)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+	}
+}
@@ -0,0 +1,572 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/stream"
+)
+
+const (
+	maxMessageLength = 1000
+	// maxConversationMembers counts the user who starts the conversation.
+	maxConversationMembers = 10
+)
+
+// Who may start a conversation with a user.
+const (
+	dmPolicyEveryone  = "everyone"
+	dmPolicyFollowing = "following"
+)
+
+var dmPolicies = []string{dmPolicyEveryone, dmPolicyFollowing}
+
+var errCantMessage = errors.New("can't message this user")
+
+type ConversationMember struct {
+	UserID uuid.UUID `json:"user_id"`
+	Handle string    `json:"handle"`
+}
+
+type Conversation struct {
+	ID          uuid.UUID            `json:"id"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+	Members     []ConversationMember `json:"members"`
+	UnreadCount int64                `json:"unread_count"`
+}
+
+type Message struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Body           string    `json:"body"`
+}
+
+func messageResponse(message database.Message) Message {
+	return Message{
+		ID:             message.ID,
+		CreatedAt:      message.CreatedAt,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		Body:           message.Body,
+	}
+}
+
+// pairKey names the one-to-one conversation between two users, whichever of
+// them starts it.
+func pairKey(a, b uuid.UUID) string {
+	if a.String() > b.String() {
+		a, b = b, a
+	}
+	return a.String() + ":" + b.String()
+}
+
+// canMessage fails with errCantMessage when either user blocked the other or
+// the recipient only accepts messages from the accounts they follow.
+func canMessage(ctx context.Context, q *database.Queries, senderID uuid.UUID, recipient database.User) error {
+	blocked, err := q.IsBlockedBetween(ctx, database.IsBlockedBetweenParams{UserID: senderID, OtherUserID: recipient.ID})
+	if err != nil {
+		return err
+	}
+
+	if blocked {
+		return errCantMessage
+	}
+
+	if recipient.DmPolicy == dmPolicyFollowing {
+		following, err := q.IsFollowing(ctx, database.IsFollowingParams{FollowerID: recipient.ID, FolloweeID: senderID})
+		if err != nil {
+			return err
+		}
+
+		if !following {
+			return errCantMessage
+		}
+	}
+
+	return nil
+}
+
+// conversationMembers looks up the members of each conversation.
+func (cfg *apiConfig) conversationMembers(ctx context.Context, conversationIDs []uuid.UUID) (map[uuid.UUID][]ConversationMember, error) {
+	rows, err := cfg.dbQueries.GetMembersForConversations(ctx, conversationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	members := map[uuid.UUID][]ConversationMember{}
+	for _, row := range rows {
+		members[row.ConversationID] = append(members[row.ConversationID], ConversationMember{UserID: row.UserID, Handle: row.Handle})
+	}
+
+	return members, nil
+}
+
+func (cfg *apiConfig) conversationResponse(ctx context.Context, conversation database.Conversation, userID uuid.UUID) (Conversation, error) {
+	members, err := cfg.conversationMembers(ctx, []uuid.UUID{conversation.ID})
+	if err != nil {
+		return Conversation{}, err
+	}
+
+	params := database.CountUnreadMessagesParams{ConversationID: conversation.ID, UserID: userID}
+	unread, err := cfg.dbQueries.CountUnreadMessages(ctx, params)
+	if err != nil {
+		return Conversation{}, err
+	}
+
+	return Conversation{
+		ID:          conversation.ID,
+		CreatedAt:   conversation.CreatedAt,
+		UpdatedAt:   conversation.UpdatedAt,
+		Members:     members[conversation.ID],
+		UnreadCount: unread,
+	}, nil
+}
+
+// authenticateMember checks that the request comes from a member of the
+// conversation in its path. Anyone else gets a 404 so conversations can't be
+// probed for.
+func (cfg *apiConfig) authenticateMember(w http.ResponseWriter, r *http.Request) (uuid.UUID, database.Conversation, bool) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return uuid.UUID{}, database.Conversation{}, false
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return uuid.UUID{}, database.Conversation{}, false
+	}
+
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid conversation id")
+		return uuid.UUID{}, database.Conversation{}, false
+	}
+
+	params := database.GetConversationForMemberParams{ConversationID: conversationID, UserID: userID}
+	conversation, err := cfg.dbQueries.GetConversationForMember(r.Context(), params)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "couldn't find conversation")
+		return uuid.UUID{}, database.Conversation{}, false
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get conversation")
+		return uuid.UUID{}, database.Conversation{}, false
+	}
+
+	return userID, conversation, true
+}
+
+func (cfg *apiConfig) createConversationHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	// Members are given by user ID or handle.
+	data := struct {
+		Members []string `json:"members"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't find user")
+		return
+	}
+
+	if isSuspended(user) {
+		respondWithError(w, http.StatusForbidden, "account is suspended")
+		return
+	}
+
+	if len(data.Members) > maxConversationMembers {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("conversation can't have more than %d members", maxConversationMembers))
+		return
+	}
+
+	recipients := []uuid.UUID{}
+	for _, ref := range data.Members {
+		member, _, err := cfg.resolveUser(r.Context(), ref)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "couldn't find user")
+			return
+		} else if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			respondWithError(w, http.StatusInternalServerError, "failed to create conversation")
+			return
+		}
+
+		if member.ID == userID || slices.Contains(recipients, member.ID) {
+			continue
+		}
+
+		err = canMessage(r.Context(), cfg.dbQueries, userID, member)
+		if errors.Is(err, errCantMessage) {
+			respondWithError(w, http.StatusForbidden, fmt.Sprintf("can't message %s", member.Handle))
+			return
+		} else if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			respondWithError(w, http.StatusInternalServerError, "failed to create conversation")
+			return
+		}
+
+		recipients = append(recipients, member.ID)
+	}
+
+	if len(recipients) == 0 {
+		respondWithError(w, http.StatusBadRequest, "conversation needs another member")
+		return
+	}
+
+	if len(recipients)+1 > maxConversationMembers {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("conversation can't have more than %d members", maxConversationMembers))
+		return
+	}
+
+	// A pair of users always shares the same one-to-one conversation.
+	key := sql.NullString{}
+	if len(recipients) == 1 {
+		key = sql.NullString{String: pairKey(userID, recipients[0]), Valid: true}
+
+		conversation, err := cfg.dbQueries.GetConversationByPairKey(r.Context(), key)
+		if err == nil {
+			resp, err := cfg.conversationResponse(r.Context(), conversation, userID)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				respondWithError(w, http.StatusInternalServerError, "failed to create conversation")
+				return
+			}
+
+			respondWithJson(w, http.StatusOK, resp)
+			return
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			fmt.Printf("Error: %v\n", err)
+			respondWithError(w, http.StatusInternalServerError, "failed to create conversation")
+			return
+		}
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to create conversation")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	conversation, err := qtx.CreateConversation(r.Context(), key)
+	for _, memberID := range append([]uuid.UUID{userID}, recipients...) {
+		if err != nil {
+			break
+		}
+		err = qtx.AddConversationMember(r.Context(), database.AddConversationMemberParams{ConversationID: conversation.ID, UserID: memberID})
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+
+	// The other user started the same conversation at the same time, so
+	// theirs is handed back like any existing one.
+	status := http.StatusCreated
+	if isUniqueViolation(err) && key.Valid {
+		status = http.StatusOK
+		conversation, err = cfg.dbQueries.GetConversationByPairKey(r.Context(), key)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to create conversation")
+		return
+	}
+
+	resp, err := cfg.conversationResponse(r.Context(), conversation, userID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to create conversation")
+		return
+	}
+
+	respondWithJson(w, status, resp)
+}
+
+func (cfg *apiConfig) getConversationsHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Conversations are ordered by their latest message.
+	params := database.GetConversationsParams{
+		UserID:          userID,
+		BeforeUpdatedAt: after.CreatedAt,
+		BeforeID:        after.ID,
+		PageSize:        limit,
+	}
+	conversations, err := cfg.dbQueries.GetConversations(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get conversations")
+		return
+	}
+
+	ids := []uuid.UUID{}
+	for _, conversation := range conversations {
+		ids = append(ids, conversation.ID)
+	}
+
+	members, err := cfg.conversationMembers(r.Context(), ids)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get conversations")
+		return
+	}
+
+	resp := Page[Conversation]{Items: []Conversation{}}
+	for _, conversation := range conversations {
+		resp.Items = append(resp.Items, Conversation{
+			ID:          conversation.ID,
+			CreatedAt:   conversation.CreatedAt,
+			UpdatedAt:   conversation.UpdatedAt,
+			Members:     members[conversation.ID],
+			UnreadCount: conversation.UnreadCount,
+		})
+	}
+	if len(conversations) > 0 {
+		last := conversations[len(conversations)-1]
+		resp.NextCursor = nextCursor(len(conversations), limit, cursor{CreatedAt: last.UpdatedAt, ID: last.ID})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) sendMessageHandler(w http.ResponseWriter, r *http.Request) {
+	userID, conversation, ok := cfg.authenticateMember(w, r)
+	if !ok {
+		return
+	}
+
+	data := struct {
+		Body string `json:"body"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	if strings.TrimSpace(data.Body) == "" {
+		respondWithError(w, http.StatusBadRequest, "message is empty")
+		return
+	}
+
+	if utf8.RuneCountInString(data.Body) > maxMessageLength {
+		respondWithError(w, http.StatusBadRequest, "message is too long")
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't find user")
+		return
+	}
+
+	if isSuspended(user) {
+		respondWithError(w, http.StatusForbidden, "account is suspended")
+		return
+	}
+
+	// A block or a policy change ends a one-to-one conversation. In a group,
+	// members who block the sender just stop seeing their messages.
+	if conversation.PairKey.Valid {
+		err = cfg.checkCanMessagePair(r.Context(), conversation.ID, userID)
+		if errors.Is(err, errCantMessage) {
+			respondWithError(w, http.StatusForbidden, err.Error())
+			return
+		} else if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			respondWithError(w, http.StatusInternalServerError, "failed to send message")
+			return
+		}
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to send message")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	params := database.CreateMessageParams{ConversationID: conversation.ID, SenderID: userID, Body: data.Body}
+	message, err := qtx.CreateMessage(r.Context(), params)
+	if err == nil {
+		err = qtx.TouchConversation(r.Context(), conversation.ID)
+	}
+	if err == nil {
+		// Sending a message means the sender has caught up.
+		err = qtx.MarkConversationRead(r.Context(), database.MarkConversationReadParams{ConversationID: conversation.ID, UserID: userID})
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to send message")
+		return
+	}
+
+	cfg.publishToConversation(r.Context(), stream.Event{
+		Kind:           stream.MessageCreated,
+		AuthorID:       userID,
+		ConversationID: conversation.ID,
+		MessageID:      message.ID,
+	})
+
+	respondWithJson(w, http.StatusCreated, messageResponse(message))
+}
+
+// checkCanMessagePair applies canMessage to the other member of a one-to-one
+// conversation.
+func (cfg *apiConfig) checkCanMessagePair(ctx context.Context, conversationID, senderID uuid.UUID) error {
+	members, err := cfg.conversationMembers(ctx, []uuid.UUID{conversationID})
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members[conversationID] {
+		if member.UserID == senderID {
+			continue
+		}
+
+		recipient, err := cfg.dbQueries.GetUserByID(ctx, member.UserID)
+		if err != nil {
+			return err
+		}
+
+		err = canMessage(ctx, cfg.dbQueries, senderID, recipient)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// publishToConversation sends an event from e.AuthorID to every other member
+// of e.ConversationID who hasn't blocked them.
+func (cfg *apiConfig) publishToConversation(ctx context.Context, e stream.Event) {
+	params := database.GetMessageRecipientsParams{ConversationID: e.ConversationID, SenderID: e.AuthorID}
+	recipients, err := cfg.dbQueries.GetMessageRecipients(ctx, params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	for _, recipient := range recipients {
+		e.UserID = recipient
+		err = cfg.events.Publish(ctx, e)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+}
+
+func (cfg *apiConfig) getMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, conversation, ok := cfg.authenticateMember(w, r)
+	if !ok {
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetMessagesParams{
+		ConversationID:  conversation.ID,
+		BeforeCreatedAt: after.CreatedAt,
+		BeforeID:        after.ID,
+		ViewerID:        userID,
+		PageSize:        limit,
+	}
+	messages, err := cfg.dbQueries.GetMessages(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get messages")
+		return
+	}
+
+	resp := Page[Message]{Items: []Message{}}
+	for _, message := range messages {
+		resp.Items = append(resp.Items, messageResponse(message))
+	}
+	if len(messages) > 0 {
+		last := messages[len(messages)-1]
+		resp.NextCursor = nextCursor(len(messages), limit, cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) markConversationReadHandler(w http.ResponseWriter, r *http.Request) {
+	userID, conversation, ok := cfg.authenticateMember(w, r)
+	if !ok {
+		return
+	}
+
+	params := database.MarkConversationReadParams{ConversationID: conversation.ID, UserID: userID}
+	err := cfg.dbQueries.MarkConversationRead(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to mark conversation read")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
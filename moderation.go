@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/moderation"
+)
+
+// Values of chirps.moderation_status.
+const (
+	chirpApproved      = "approved"
+	chirpPendingReview = "pending_review"
+)
+
+func moderationStatus(result moderation.Result) string {
+	if result.Action == moderation.Review {
+		return chirpPendingReview
+	}
+
+	return chirpApproved
+}
+
+func (cfg *apiConfig) recordModerationDecisions(ctx context.Context, userID uuid.UUID, chirpID uuid.NullUUID, body string, result moderation.Result) {
+	for _, decision := range result.Decisions {
+		params := database.CreateModerationDecisionParams{
+			UserID:  userID,
+			ChirpID: chirpID,
+			Body:    body,
+			Filter:  decision.Filter,
+			Action:  decision.Action.String(),
+			Reason:  decision.Reason,
+			Matches: decision.Matches,
+		}
+
+		err := cfg.dbQueries.CreateModerationDecision(ctx, params)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+}
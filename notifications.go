@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/notifications"
+	"github.com/matt-horst/chirpy/internal/stream"
+)
+
+type NotificationGroup struct {
+	Type      string      `json:"type"`
+	ChirpID   *uuid.UUID  `json:"chirp_id,omitempty"`
+	IDs       []uuid.UUID `json:"ids"`
+	ActorIDs  []uuid.UUID `json:"actor_ids"`
+	Count     int         `json:"count"`
+	Summary   string      `json:"summary"`
+	CreatedAt time.Time   `json:"created_at"`
+	Read      bool        `json:"read"`
+}
+
+// notify records that actor did something the user may want to hear about
+// and reports whether it did. Nothing is recorded when the user acts on their
+// own account, has turned the kind off or has muted the actor, or when the
+// actor is shadowbanned. Pass the users that were notified to
+// publishNotifications once the surrounding transaction has committed.
+func notify(ctx context.Context, q *database.Queries, userID, actorID uuid.UUID, kind notifications.Kind, chirpID uuid.NullUUID) (bool, error) {
+	params := database.CreateNotificationParams{
+		UserID:   userID,
+		ActorID:  actorID,
+		Kind:     string(kind),
+		ChirpID:  chirpID,
+		GroupKey: notifications.GroupKey(kind, chirpID),
+	}
+	created, err := q.CreateNotification(ctx, params)
+	return created > 0, err
+}
+
+// publishNotifications tells the live connections of each user that they
+// have something new.
+func (cfg *apiConfig) publishNotifications(userIDs ...uuid.UUID) {
+	for _, userID := range userIDs {
+		err := cfg.events.Publish(context.Background(), stream.Event{Kind: stream.NotificationCreated, UserID: userID})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+}
+
+func (cfg *apiConfig) getNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetNotificationsParams{
+		UserID:          userID,
+		BeforeCreatedAt: after.CreatedAt,
+		BeforeID:        after.ID,
+		UnreadOnly:      r.URL.Query().Get("unread") == "true",
+		PageSize:        limit,
+	}
+	rows, err := cfg.dbQueries.GetNotifications(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get notifications")
+		return
+	}
+
+	unread, err := cfg.dbQueries.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get notifications")
+		return
+	}
+
+	page := []notifications.Notification{}
+	for _, row := range rows {
+		page = append(page, notifications.Notification{
+			ID:          row.ID,
+			CreatedAt:   row.CreatedAt,
+			Kind:        notifications.Kind(row.Kind),
+			ChirpID:     row.ChirpID,
+			GroupKey:    row.GroupKey,
+			ActorID:     row.ActorID,
+			ActorHandle: row.ActorHandle,
+			Read:        row.ReadAt.Valid,
+		})
+	}
+
+	// Groups only span a single page, so a large group may continue on the
+	// next one.
+	resp := struct {
+		Page[NotificationGroup]
+		UnreadCount int64 `json:"unread_count"`
+	}{
+		Page:        Page[NotificationGroup]{Items: []NotificationGroup{}},
+		UnreadCount: unread,
+	}
+	for _, group := range notifications.GroupNotifications(page) {
+		item := NotificationGroup{
+			Type:      string(group.Kind),
+			IDs:       group.IDs,
+			ActorIDs:  group.ActorIDs,
+			Count:     len(group.IDs),
+			Summary:   group.Summary(),
+			CreatedAt: group.LatestAt,
+			Read:      group.Read,
+		}
+		if group.ChirpID.Valid {
+			item.ChirpID = &group.ChirpID.UUID
+		}
+		resp.Items = append(resp.Items, item)
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		resp.NextCursor = nextCursor(len(rows), limit, cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) getUnreadNotificationCountHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	unread, err := cfg.dbQueries.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to count notifications")
+		return
+	}
+
+	resp := struct {
+		UnreadCount int64 `json:"unread_count"`
+	}{UnreadCount: unread}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+// markNotificationsReadHandler marks the given notifications read, or all of
+// them when no IDs are given.
+func (cfg *apiConfig) markNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	data := struct {
+		IDs []uuid.UUID `json:"ids"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	if len(data.IDs) == 0 {
+		_, err = cfg.dbQueries.MarkAllNotificationsRead(r.Context(), userID)
+	} else {
+		params := database.MarkNotificationsReadParams{UserID: userID, Ids: data.IDs}
+		_, err = cfg.dbQueries.MarkNotificationsRead(r.Context(), params)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to mark notifications read")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) getNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	resp, err := cfg.notificationPreferences(r.Context(), userID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get preferences")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+// updateNotificationPreferencesHandler takes a map of notification kinds to
+// whether they're enabled. Kinds left out keep their current setting.
+func (cfg *apiConfig) updateNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	data := map[string]bool{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	for kind := range data {
+		if _, ok := notifications.ParseKind(kind); !ok {
+			respondWithError(w, http.StatusBadRequest, "unknown notification type: "+kind)
+			return
+		}
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to update preferences")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	for kind, enabled := range data {
+		params := database.SetNotificationPreferenceParams{UserID: userID, Kind: kind, Enabled: enabled}
+		err = qtx.SetNotificationPreference(r.Context(), params)
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to update preferences")
+		return
+	}
+
+	resp, err := cfg.notificationPreferences(r.Context(), userID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get preferences")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+// notificationPreferences returns whether each kind of notification is
+// enabled for a user. Every kind is enabled until the user turns it off.
+func (cfg *apiConfig) notificationPreferences(ctx context.Context, userID uuid.UUID) (map[string]bool, error) {
+	preferences := map[string]bool{}
+	for _, kind := range notifications.Kinds {
+		preferences[string(kind)] = true
+	}
+
+	rows, err := cfg.dbQueries.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Preferences saved for kinds that no longer exist are left out.
+	for _, row := range rows {
+		if _, ok := preferences[row.Kind]; ok {
+			preferences[row.Kind] = row.Enabled
+		}
+	}
+
+	return preferences, nil
+}
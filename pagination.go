@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// parseLimitOffset reads the `limit` and `offset` query parameters, falling
+// back to the defaults when they're missing or invalid.
+func parseLimitOffset(r *http.Request) (int32, int32) {
+	limit := int32(defaultPageSize)
+	if value, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && value > 0 {
+		limit = int32(min(value, maxPageSize))
+	}
+
+	offset := int32(0)
+	if value, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && value > 0 {
+		offset = int32(value)
+	}
+
+	return limit, offset
+}
+
+// Page is a page of a list that uses cursor pagination. NextCursor is empty on
+// the last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// cursor is a position in a list ordered newest first by (timestamp, id).
+// Pages hold the items that sort strictly after their cursor.
+type cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// firstPageCursor sorts before every item, so its page starts with the newest.
+func firstPageCursor() cursor {
+	return cursor{CreatedAt: time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC), ID: uuid.Max}
+}
+
+func (c cursor) String() string {
+	raw := fmt.Sprintf("%d:%s", c.CreatedAt.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, errors.New("invalid cursor")
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return cursor{}, errors.New("invalid cursor")
+	}
+
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return cursor{}, errors.New("invalid cursor")
+	}
+
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return cursor{}, errors.New("invalid cursor")
+	}
+
+	return cursor{CreatedAt: time.Unix(0, unixNano).UTC(), ID: parsedID}, nil
+}
+
+// parseCursorPage reads the `cursor` and `limit` query parameters.
+func parseCursorPage(r *http.Request) (cursor, int32, error) {
+	limit, _ := parseLimitOffset(r)
+
+	value := r.URL.Query().Get("cursor")
+	if value == "" {
+		return firstPageCursor(), limit, nil
+	}
+
+	c, err := decodeCursor(value)
+	return c, limit, err
+}
+
+// nextCursor returns the cursor of the page after one that ended at last, or
+// an empty string when the page wasn't full and so was the last one.
+func nextCursor(count int, limit int32, last cursor) string {
+	if count < int(limit) {
+		return ""
+	}
+
+	return last.String()
+}
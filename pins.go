@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/pins"
+)
+
+// pinChirpHandler pins one of the user's chirps to their profile, after the
+// ones already pinned.
+func (cfg *apiConfig) pinChirpHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id")
+		return
+	}
+
+	chirp, err := cfg.dbQueries.GetSingleChirp(r.Context(), chirpID)
+	if err != nil || chirp.Status != chirpPublished {
+		respondWithError(w, http.StatusNotFound, "no chirp found")
+		return
+	}
+
+	if chirp.UserID.UUID != userID {
+		respondWithError(w, http.StatusForbidden, pins.ErrNotAuthor.Error())
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't find user")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to pin chirp")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	// Pins are counted under a lock on the user so concurrent requests can't
+	// go over the limit.
+	var pinned []uuid.UUID
+	err = qtx.LockPinnedChirps(r.Context(), userID)
+	if err == nil {
+		pinned, err = qtx.GetPinnedChirpIDs(r.Context(), userID)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to pin chirp")
+		return
+	}
+
+	if slices.Contains(pinned, chirp.ID) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	limit := pins.Limit(user.IsChirpyRed)
+	if len(pinned) >= limit {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("can't pin more than %d chirps", limit))
+		return
+	}
+
+	_, err = qtx.PinChirp(r.Context(), database.PinChirpParams{UserID: userID, ChirpID: chirp.ID})
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to pin chirp")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unpinChirpHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id")
+		return
+	}
+
+	_, err = cfg.dbQueries.UnpinChirp(r.Context(), database.UnpinChirpParams{UserID: userID, ChirpID: chirpID})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to unpin chirp")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reorderPinsHandler sets the order of the user's pinned chirps. The request
+// lists every pinned chirp, first to last.
+func (cfg *apiConfig) reorderPinsHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	data := struct {
+		ChirpIDs []uuid.UUID `json:"chirp_ids"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to reorder pins")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	var pinned []uuid.UUID
+	err = qtx.LockPinnedChirps(r.Context(), userID)
+	if err == nil {
+		pinned, err = qtx.GetPinnedChirpIDs(r.Context(), userID)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to reorder pins")
+		return
+	}
+
+	err = pins.ValidateOrder(pinned, data.ChirpIDs)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for i, chirpID := range data.ChirpIDs {
+		params := database.SetPinPositionParams{UserID: userID, ChirpID: chirpID, Position: int32(i)}
+		err = qtx.SetPinPosition(r.Context(), params)
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to reorder pins")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/polka"
+	"github.com/matt-horst/chirpy/internal/subscriptions"
+)
+
+const (
+	polkaEventProcessed = "processed"
+
+	// maxPolkaPayload caps the webhook bodies we'll read and store.
+	maxPolkaPayload = 64 << 10
+)
+
+var errPolkaEventProcessed = errors.New("event has already been processed")
+
+// PolkaEvent is a stored webhook, as shown to moderators.
+type PolkaEvent struct {
+	ID          uuid.UUID       `json:"id"`
+	EventID     string          `json:"event_id"`
+	ReceivedAt  time.Time       `json:"received_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Event       string          `json:"event"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int32           `json:"attempts"`
+	LastError   string          `json:"last_error"`
+	ProcessedAt *time.Time      `json:"processed_at"`
+}
+
+// processPolkaEvent applies a stored event from its saved payload. An event is
+// applied at most once; if applying it fails, the error is recorded so the
+// event can be reprocessed later.
+func (cfg *apiConfig) processPolkaEvent(ctx context.Context, eventID string) error {
+	err := cfg.applyStoredPolkaEvent(ctx, eventID)
+	if err == nil || errors.Is(err, errPolkaEventProcessed) || errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	params := database.MarkPolkaEventFailedParams{EventID: eventID, LastError: err.Error()}
+	markErr := cfg.dbQueries.MarkPolkaEventFailed(ctx, params)
+	if markErr != nil {
+		fmt.Printf("Error: %v\n", markErr)
+	}
+
+	return err
+}
+
+func (cfg *apiConfig) applyStoredPolkaEvent(ctx context.Context, eventID string) error {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	// The lock keeps a redelivery and a reprocess from applying the event
+	// twice.
+	stored, err := qtx.GetPolkaEventForUpdate(ctx, eventID)
+	if err != nil {
+		return err
+	}
+
+	if stored.Status == polkaEventProcessed {
+		return errPolkaEventProcessed
+	}
+
+	event := polka.Event{}
+	err = json.Unmarshal(stored.Payload, &event)
+	if err == nil {
+		err = applyPolkaEvent(ctx, qtx, event)
+	}
+	if err == nil {
+		err = qtx.MarkPolkaEventProcessed(ctx, eventID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// getFailedPolkaEventsHandler lists the events that couldn't be applied, most
+// recently received first.
+func (cfg *apiConfig) getFailedPolkaEventsHandler(w http.ResponseWriter, r *http.Request) {
+	_, ok := cfg.authenticateModerator(w, r)
+	if !ok {
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetFailedPolkaEventsParams{
+		BeforeReceivedAt: after.CreatedAt,
+		BeforeID:         after.ID,
+		PageSize:         limit,
+	}
+	rows, err := cfg.dbQueries.GetFailedPolkaEvents(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get events")
+		return
+	}
+
+	resp := Page[PolkaEvent]{Items: []PolkaEvent{}}
+	for _, row := range rows {
+		event := PolkaEvent{
+			ID:         row.ID,
+			EventID:    row.EventID,
+			ReceivedAt: row.ReceivedAt,
+			UpdatedAt:  row.UpdatedAt,
+			Event:      row.Event,
+			Payload:    json.RawMessage(row.Payload),
+			Status:     row.Status,
+			Attempts:   row.Attempts,
+			LastError:  row.LastError,
+		}
+		if row.ProcessedAt.Valid {
+			event.ProcessedAt = &row.ProcessedAt.Time
+		}
+		resp.Items = append(resp.Items, event)
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		resp.NextCursor = nextCursor(len(rows), limit, cursor{CreatedAt: last.ReceivedAt, ID: last.ID})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+// reprocessPolkaEventHandler applies a stored event again, usually one that
+// failed because of a bug or a user that didn't exist yet.
+func (cfg *apiConfig) reprocessPolkaEventHandler(w http.ResponseWriter, r *http.Request) {
+	_, ok := cfg.authenticateModerator(w, r)
+	if !ok {
+		return
+	}
+
+	err := cfg.processPolkaEvent(r.Context(), r.PathValue("eventID"))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "no event found")
+		return
+	} else if errors.Is(err, errPolkaEventProcessed) {
+		respondWithError(w, http.StatusConflict, err.Error())
+		return
+	} else if errors.Is(err, errUnknownPolkaUser) || errors.Is(err, subscriptions.ErrInvalidPeriod) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to process event")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
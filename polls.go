@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/polls"
+)
+
+// Poll is the state of a poll as one viewer sees it. Tallies are left out
+// until the viewer has voted or the poll has closed.
+type Poll struct {
+	ClosesAt       time.Time    `json:"closes_at"`
+	Closed         bool         `json:"closed"`
+	MultipleChoice bool         `json:"multiple_choice"`
+	Options        []PollOption `json:"options"`
+	VoterCount     *int32       `json:"voter_count,omitempty"`
+	// Votes are the positions of the options the viewer chose.
+	Votes []int32 `json:"votes"`
+}
+
+type PollOption struct {
+	Text  string `json:"text"`
+	Votes *int32 `json:"votes,omitempty"`
+}
+
+type pollRequest struct {
+	Options        []string  `json:"options"`
+	ClosesAt       time.Time `json:"closes_at"`
+	MultipleChoice bool      `json:"multiple_choice"`
+}
+
+func (data *pollRequest) validate() error {
+	options, err := polls.ValidateOptions(data.Options)
+	if err != nil {
+		return err
+	}
+	data.Options = options
+
+	return polls.ValidateClosesAt(data.ClosesAt, time.Now())
+}
+
+// createPoll attaches a validated poll to a chirp.
+func createPoll(ctx context.Context, q *database.Queries, chirpID uuid.UUID, data pollRequest) error {
+	params := database.CreatePollParams{
+		ChirpID:        chirpID,
+		ClosesAt:       data.ClosesAt.UTC(),
+		MultipleChoice: data.MultipleChoice,
+	}
+	err := q.CreatePoll(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	for i, option := range data.Options {
+		err = q.CreatePollOption(ctx, database.CreatePollOptionParams{ChirpID: chirpID, Position: int32(i), Text: option})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pollResponses looks up the polls attached to the chirps, keyed by chirp.
+func (cfg *apiConfig) pollResponses(ctx context.Context, chirpIDs []uuid.UUID, viewerID uuid.NullUUID) (map[uuid.UUID]*Poll, error) {
+	resp := map[uuid.UUID]*Poll{}
+
+	rows, err := cfg.dbQueries.GetPollsForChirps(ctx, chirpIDs)
+	if err != nil || len(rows) == 0 {
+		return resp, err
+	}
+
+	ids := []uuid.UUID{}
+	for _, row := range rows {
+		ids = append(ids, row.ChirpID)
+	}
+
+	options, err := cfg.dbQueries.GetPollOptionsForChirps(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	votes := map[uuid.UUID][]int32{}
+	if viewerID.Valid {
+		params := database.GetPollVotesByUserParams{ChirpIds: ids, UserID: viewerID.UUID}
+		rows, err := cfg.dbQueries.GetPollVotesByUser(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			votes[row.ChirpID] = row.Positions
+		}
+	}
+
+	now := time.Now().UTC()
+	for _, row := range rows {
+		choices, voted := votes[row.ChirpID]
+		if choices == nil {
+			choices = []int32{}
+		}
+
+		poll := &Poll{
+			ClosesAt:       row.ClosesAt,
+			Closed:         !now.Before(row.ClosesAt),
+			MultipleChoice: row.MultipleChoice,
+			Options:        []PollOption{},
+			Votes:          choices,
+		}
+
+		showResults := polls.ShowResults(voted, row.ClosesAt, now)
+		if showResults {
+			poll.VoterCount = &row.VoterCount
+		}
+
+		for _, option := range options {
+			if option.ChirpID != row.ChirpID {
+				continue
+			}
+
+			pollOption := PollOption{Text: option.Text}
+			if showResults {
+				pollOption.Votes = &option.VoteCount
+			}
+			poll.Options = append(poll.Options, pollOption)
+		}
+
+		resp[row.ChirpID] = poll
+	}
+
+	return resp, nil
+}
+
+// votePollHandler casts the user's one ballot in a poll. Counters are bumped
+// with single UPDATE statements in the ballot's transaction, so concurrent
+// votes can't lose each other's counts.
+func (cfg *apiConfig) votePollHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id")
+		return
+	}
+
+	// Choices are option positions, starting at 0.
+	data := struct {
+		Choices []int `json:"choices"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	chirp, err := cfg.dbQueries.GetSingleChirp(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "no chirp found")
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to vote")
+		return
+	}
+
+	viewerID := uuid.NullUUID{UUID: userID, Valid: true}
+	visible, err := cfg.canViewChirp(r.Context(), chirp, viewerID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to vote")
+		return
+	}
+
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "no chirp found")
+		return
+	}
+
+	poll, err := cfg.dbQueries.GetPoll(r.Context(), chirp.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "chirp has no poll")
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to vote")
+		return
+	}
+
+	if !time.Now().UTC().Before(poll.ClosesAt) {
+		respondWithError(w, http.StatusConflict, polls.ErrClosed.Error())
+		return
+	}
+
+	options, err := cfg.dbQueries.GetPollOptionsForChirps(r.Context(), []uuid.UUID{chirp.ID})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to vote")
+		return
+	}
+
+	positions, err := polls.ValidateChoices(data.Choices, len(options), poll.MultipleChoice)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to vote")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	params := database.CreatePollVoteParams{ChirpID: chirp.ID, UserID: userID, Positions: positions}
+	created, err := qtx.CreatePollVote(r.Context(), params)
+	if err == nil && created == 0 {
+		respondWithError(w, http.StatusConflict, polls.ErrAlreadyVoted.Error())
+		return
+	}
+	if err == nil {
+		err = qtx.CountPollVote(r.Context(), database.CountPollVoteParams{ChirpID: chirp.ID, Positions: positions})
+	}
+	if err == nil {
+		err = qtx.IncrementPollVoterCount(r.Context(), chirp.ID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to vote")
+		return
+	}
+
+	resp, err := cfg.chirpResponse(r.Context(), chirp, viewerID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to vote")
+		return
+	}
+
+	respondWithJson(w, http.StatusCreated, resp)
+}
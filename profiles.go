@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+)
+
+// How long an old handle keeps pointing at its user after a rename.
+const handleRedirectTTL = 30 * 24 * time.Hour
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+)
+
+var handlePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,15}$`)
+
+// Handles nobody can register because they'd be confused with the service or
+// its staff. Compared case-insensitively.
+var reservedHandles = []string{
+	"about",
+	"admin",
+	"administrator",
+	"api",
+	"app",
+	"chirpy",
+	"help",
+	"login",
+	"logout",
+	"me",
+	"mod",
+	"moderator",
+	"null",
+	"official",
+	"profile",
+	"root",
+	"settings",
+	"staff",
+	"support",
+	"system",
+}
+
+var errHandleTaken = errors.New("handle is already taken")
+
+type Profile struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Handle         string    `json:"handle"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	AvatarURL      string    `json:"avatar_url"`
+	Locale         string    `json:"locale"`
+	DMPolicy       string    `json:"dm_policy"`
+	IsPrivate      bool      `json:"is_private"`
+	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+}
+
+// trimHandle strips the whitespace and leading '@' users tend to type.
+func trimHandle(handle string) string {
+	return strings.TrimPrefix(strings.TrimSpace(handle), "@")
+}
+
+func validateHandle(handle string) error {
+	if !handlePattern.MatchString(handle) {
+		return errors.New("handle must be 3 to 15 letters, numbers or underscores")
+	}
+
+	if slices.Contains(reservedHandles, strings.ToLower(handle)) {
+		return errors.New("handle is reserved")
+	}
+
+	return nil
+}
+
+func defaultHandle() string {
+	return "user_" + strings.ReplaceAll(uuid.New().String(), "-", "")[:10]
+}
+
+// checkHandleAvailable fails when the handle belongs to another user, either
+// as their current handle or as one they recently renamed away from.
+func checkHandleAvailable(ctx context.Context, q *database.Queries, handle string, userID uuid.NullUUID) error {
+	user, err := q.GetUserByHandle(ctx, handle)
+	if err == nil && user.ID != userID.UUID {
+		return errHandleTaken
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	redirect, err := q.GetHandleRedirect(ctx, handle)
+	if err == nil && redirect.UserID != userID.UUID {
+		return errHandleTaken
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// resolveUser looks a user up by ID or by handle, following the redirects left
+// behind by renames. It reports whether it had to follow a redirect.
+func (cfg *apiConfig) resolveUser(ctx context.Context, ref string) (database.User, bool, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		user, err := cfg.dbQueries.GetUserByID(ctx, id)
+		return user, false, err
+	}
+
+	handle := trimHandle(ref)
+	user, err := cfg.dbQueries.GetUserByHandle(ctx, handle)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return user, false, err
+	}
+
+	redirect, err := cfg.dbQueries.GetHandleRedirect(ctx, handle)
+	if err != nil {
+		return database.User{}, false, err
+	}
+
+	user, err = cfg.dbQueries.GetUserByID(ctx, redirect.UserID)
+	return user, true, err
+}
+
+func (cfg *apiConfig) getProfileHandler(w http.ResponseWriter, r *http.Request) {
+	user, redirected, err := cfg.resolveUser(r.Context(), r.PathValue("handle"))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "couldn't find user")
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get profile")
+		return
+	}
+
+	if redirected {
+		http.Redirect(w, r, "/api/users/"+url.PathEscape(user.Handle), http.StatusMovedPermanently)
+		return
+	}
+
+	counts, err := cfg.dbQueries.GetFollowCounts(r.Context(), user.ID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get profile")
+		return
+	}
+
+	resp := Profile{
+		ID:             user.ID,
+		CreatedAt:      user.CreatedAt,
+		Handle:         user.Handle,
+		DisplayName:    user.DisplayName,
+		Bio:            user.Bio,
+		AvatarURL:      user.AvatarUrl,
+		Locale:         user.Locale,
+		DMPolicy:       user.DmPolicy,
+		IsPrivate:      user.IsPrivate,
+		IsChirpyRed:    user.IsChirpyRed,
+		FollowerCount:  counts.FollowerCount,
+		FollowingCount: counts.FollowingCount,
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) updateProfileHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "missing access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	// Fields left out of the request keep their current value.
+	data := struct {
+		Handle      *string `json:"handle"`
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		AvatarURL   *string `json:"avatar_url"`
+		// Uses an uploaded image as the avatar in place of avatar_url.
+		AvatarMediaID *uuid.UUID `json:"avatar_media_id"`
+		Locale        *string    `json:"locale"`
+		DMPolicy      *string    `json:"dm_policy"`
+		IsPrivate     *bool      `json:"is_private"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to update profile")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	user, err := qtx.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't find user")
+		return
+	}
+
+	params := database.UpdateUserProfileParams{
+		ID:          user.ID,
+		Handle:      user.Handle,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarUrl:   user.AvatarUrl,
+		Locale:      user.Locale,
+		DmPolicy:    user.DmPolicy,
+		IsPrivate:   user.IsPrivate,
+	}
+
+	if data.DisplayName != nil {
+		if utf8.RuneCountInString(*data.DisplayName) > maxDisplayNameLength {
+			respondWithError(w, http.StatusBadRequest, "display name is too long")
+			return
+		}
+		params.DisplayName = strings.TrimSpace(*data.DisplayName)
+	}
+
+	if data.Bio != nil {
+		if utf8.RuneCountInString(*data.Bio) > maxBioLength {
+			respondWithError(w, http.StatusBadRequest, "bio is too long")
+			return
+		}
+		params.Bio = *data.Bio
+	}
+
+	if data.AvatarURL != nil {
+		avatar, err := url.Parse(*data.AvatarURL)
+		if *data.AvatarURL != "" && (err != nil || (avatar.Scheme != "https" && avatar.Scheme != "http")) {
+			respondWithError(w, http.StatusBadRequest, "invalid avatar url")
+			return
+		}
+		params.AvatarUrl = *data.AvatarURL
+	}
+
+	if data.Locale != nil {
+		locale, ok := normalizeLocale(*data.Locale)
+		if !ok {
+			respondWithError(w, http.StatusBadRequest, "invalid locale")
+			return
+		}
+		params.Locale = locale
+	}
+
+	if data.DMPolicy != nil {
+		if !slices.Contains(dmPolicies, *data.DMPolicy) {
+			respondWithError(w, http.StatusBadRequest, "invalid dm policy")
+			return
+		}
+		params.DmPolicy = *data.DMPolicy
+	}
+
+	if data.IsPrivate != nil {
+		// Going public lets in everyone who was waiting.
+		if user.IsPrivate && !*data.IsPrivate {
+			err = approveFollowRequests(r.Context(), qtx, user.ID)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				respondWithError(w, http.StatusInternalServerError, "failed to update profile")
+				return
+			}
+		}
+		params.IsPrivate = *data.IsPrivate
+	}
+
+	if data.AvatarMediaID != nil {
+		files, err := cfg.lookupOwnMedia(r.Context(), user.ID, []uuid.UUID{*data.AvatarMediaID})
+		if errors.Is(err, errInvalidMedia) {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		} else if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			respondWithError(w, http.StatusInternalServerError, "failed to update profile")
+			return
+		}
+		params.AvatarUrl = mediaURL(files[0].ThumbnailKey)
+	}
+
+	if data.Handle != nil && trimHandle(*data.Handle) != user.Handle {
+		handle := trimHandle(*data.Handle)
+		err = validateHandle(handle)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err = checkHandleAvailable(r.Context(), qtx, handle, uuid.NullUUID{UUID: user.ID, Valid: true})
+		if errors.Is(err, errHandleTaken) {
+			respondWithError(w, http.StatusConflict, err.Error())
+			return
+		} else if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			respondWithError(w, http.StatusInternalServerError, "failed to update profile")
+			return
+		}
+
+		// A change of case alone keeps the same handle, so it needs no redirect.
+		if !strings.EqualFold(handle, user.Handle) {
+			redirectParams := database.CreateHandleRedirectParams{
+				OldHandle: user.Handle,
+				UserID:    user.ID,
+				ExpiresAt: time.Now().UTC().Add(handleRedirectTTL),
+			}
+			err = qtx.CreateHandleRedirect(r.Context(), redirectParams)
+			if err == nil {
+				// Taking back an old handle retires its redirect.
+				err = qtx.DeleteHandleRedirect(r.Context(), handle)
+			}
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				respondWithError(w, http.StatusInternalServerError, "failed to update profile")
+				return
+			}
+		}
+
+		params.Handle = handle
+	}
+
+	user, err = qtx.UpdateUserProfile(r.Context(), params)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, errHandleTaken.Error())
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to update profile")
+		return
+	}
+
+	resp := Profile{
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		Handle:      user.Handle,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarURL:   user.AvatarUrl,
+		Locale:      user.Locale,
+		DMPolicy:    user.DmPolicy,
+		IsPrivate:   user.IsPrivate,
+		IsChirpyRed: user.IsChirpyRed,
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/ratelimit"
+)
+
+const (
+	// redCacheTTL is how long the rate limiter trusts a user's Chirpy Red
+	// status before looking it up again.
+	redCacheTTL = time.Minute
+	// redCacheSize bounds the users remembered at once.
+	redCacheSize = 10000
+)
+
+// rateLimitPolicy limits one or more routes. Chirpy Red users get RedLimit in
+// place of Limit, and a policy with a zero Burst doesn't limit at all.
+type rateLimitPolicy struct {
+	Name     string
+	Limit    ratelimit.Limit
+	RedLimit ratelimit.Limit
+}
+
+var defaultRateLimit = rateLimitPolicy{
+	Name:     "api",
+	Limit:    ratelimit.PerMinute(120),
+	RedLimit: ratelimit.PerMinute(300),
+}
+
+// Route patterns, as registered on the mux, that don't use defaultRateLimit.
+var rateLimits = map[string]rateLimitPolicy{
+	"POST /api/chirps": {
+		Name:     "create_chirp",
+		Limit:    ratelimit.PerMinute(10),
+		RedLimit: ratelimit.PerMinute(30),
+	},
+	"PUT /api/chirps/{chirpID}": {
+		Name:     "update_chirp",
+		Limit:    ratelimit.PerMinute(10),
+		RedLimit: ratelimit.PerMinute(30),
+	},
+	"POST /api/media": {
+		Name:     "upload_media",
+		Limit:    ratelimit.PerMinute(10),
+		RedLimit: ratelimit.PerMinute(30),
+	},
+	"POST /api/conversations/{conversationID}/messages": {
+		Name:     "send_message",
+		Limit:    ratelimit.PerMinute(30),
+		RedLimit: ratelimit.PerMinute(60),
+	},
+	"POST /api/login": {
+		Name:     "login",
+		Limit:    ratelimit.PerMinute(10),
+		RedLimit: ratelimit.PerMinute(10),
+	},
+	"POST /api/users": {
+		Name:     "create_user",
+		Limit:    ratelimit.PerMinute(5),
+		RedLimit: ratelimit.PerMinute(5),
+	},
+	"GET /api/healthz":         {},
+	"POST /api/polka/webhooks": {},
+}
+
+// middlewareRateLimit applies the rate limit policy of the route the mux would
+// route each request to. Requests are keyed by user when they carry a valid
+// access token and by IP address otherwise.
+func (cfg *apiConfig) middlewareRateLimit(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		if !strings.HasPrefix(r.URL.Path, "/api/") || pattern == "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		policy, ok := rateLimits[pattern]
+		if !ok {
+			policy = defaultRateLimit
+		}
+
+		if policy.Limit.Burst == 0 {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		key, limit := cfg.rateLimitKey(r, policy)
+		result, err := cfg.rateLimiter.Take(r.Context(), key, limit)
+		if err != nil {
+			// Don't take the API down with the rate limiter.
+			fmt.Printf("Error: %v\n", err)
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Burst, ceilSeconds(limit.Window())))
+
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			respondWithError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (cfg *apiConfig) rateLimitKey(r *http.Request, policy rateLimitPolicy) (string, ratelimit.Limit) {
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err == nil {
+		userID, err := auth.ValidateJWT(tokenString, cfg.secret)
+		if err == nil {
+			limit := policy.Limit
+			if cfg.redCache.isChirpyRed(r.Context(), cfg.dbQueries, userID) {
+				limit = policy.RedLimit
+			}
+
+			return policy.Name + ":user:" + userID.String(), limit
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return policy.Name + ":ip:" + host, policy.Limit
+}
+
+type redCacheEntry struct {
+	isChirpyRed bool
+	expiresAt   time.Time
+}
+
+// redCache remembers which users have Chirpy Red, so rate limiting doesn't
+// cost a database round trip on every request.
+type redCache struct {
+	mu      sync.Mutex
+	entries map[uuid.UUID]redCacheEntry
+}
+
+func newRedCache() *redCache {
+	return &redCache{entries: map[uuid.UUID]redCacheEntry{}}
+}
+
+// isChirpyRed reports whether the user has Chirpy Red. Lookups that fail
+// aren't remembered and count as no.
+func (c *redCache) isChirpyRed(ctx context.Context, q *database.Queries, userID uuid.UUID) bool {
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[userID]
+	c.mu.Unlock()
+
+	if ok && now.Before(entry.expiresAt) {
+		return entry.isChirpyRed
+	}
+
+	user, err := q.GetUserByID(ctx, userID)
+	if err != nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= redCacheSize {
+		for id, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, id)
+			}
+		}
+		if len(c.entries) >= redCacheSize {
+			clear(c.entries)
+		}
+	}
+	c.entries[userID] = redCacheEntry{isChirpyRed: user.IsChirpyRed, expiresAt: now.Add(redCacheTTL)}
+
+	return user.IsChirpyRed
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// cleanupRateLimits periodically deletes idle buckets from the postgres store.
+func cleanupRateLimits(store *ratelimit.PostgresStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := store.Cleanup(context.Background(), time.Hour)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/notifications"
+	"github.com/matt-horst/chirpy/internal/stream"
+)
+
+var reportReasons = []string{
+	"spam",
+	"harassment",
+	"hate",
+	"violence",
+	"sexual",
+	"misinformation",
+	"other",
+}
+
+var moderationActions = []string{
+	"dismiss",
+	"hide",
+	"delete",
+	"suspend",
+}
+
+// Values of chirp_reports.status.
+const (
+	reportOpen      = "open"
+	reportDismissed = "dismissed"
+	reportActioned  = "actioned"
+)
+
+// Values of chirps.moderation_status set by moderators.
+const (
+	chirpHidden = "hidden"
+)
+
+type ChirpReport struct {
+	ID        uuid.UUID     `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	ChirpID   uuid.NullUUID `json:"chirp_id"`
+	Reason    string        `json:"reason"`
+	Details   string        `json:"details"`
+	Status    string        `json:"status"`
+}
+
+type ReportedChirp struct {
+	ChirpID          uuid.UUID `json:"chirp_id"`
+	UserID           uuid.UUID `json:"user_id"`
+	Body             string    `json:"body"`
+	ModerationStatus string    `json:"moderation_status"`
+	ReportCount      int64     `json:"report_count"`
+	Reasons          []string  `json:"reasons"`
+}
+
+func newChirpReport(report database.ChirpReport) ChirpReport {
+	return ChirpReport{
+		ID:        report.ID,
+		CreatedAt: report.CreatedAt,
+		UpdatedAt: report.UpdatedAt,
+		ChirpID:   report.ChirpID,
+		Reason:    report.Reason,
+		Details:   report.Details,
+		Status:    report.Status,
+	}
+}
+
+// authenticateModerator validates the access token and checks that it belongs
+// to a moderator. It writes the error response itself when it returns false.
+func (cfg *apiConfig) authenticateModerator(w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return database.User{}, false
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return database.User{}, false
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't find user")
+		return database.User{}, false
+	}
+
+	if !user.IsModerator {
+		respondWithError(w, http.StatusForbidden, "requires moderator")
+		return database.User{}, false
+	}
+
+	return user, true
+}
+
+func (cfg *apiConfig) createChirpReportHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id")
+		return
+	}
+
+	data := struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	if !slices.Contains(reportReasons, data.Reason) {
+		respondWithError(w, http.StatusBadRequest, "invalid report reason")
+		return
+	}
+
+	chirp, err := cfg.dbQueries.GetSingleChirp(r.Context(), chirpID)
+	if err != nil || chirp.ModerationStatus != chirpApproved {
+		respondWithError(w, http.StatusNotFound, "no chirp found")
+		return
+	}
+
+	// Chirps the reporter can't see are reported as missing, so reports can't
+	// be used to probe for them.
+	visible, err := cfg.canViewChirp(r.Context(), chirp, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to report chirp")
+		return
+	}
+
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "no chirp found")
+		return
+	}
+
+	if chirp.UserID.UUID == userID {
+		respondWithError(w, http.StatusBadRequest, "can't report your own chirp")
+		return
+	}
+
+	params := database.CreateChirpReportParams{
+		ChirpID:    uuid.NullUUID{UUID: chirpID, Valid: true},
+		ReporterID: userID,
+		Reason:     data.Reason,
+		Details:    data.Details,
+	}
+	report, err := cfg.dbQueries.CreateChirpReport(r.Context(), params)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The reporter already reported this chirp, hand back the original report.
+		existingParams := database.GetChirpReportByReporterParams{ChirpID: params.ChirpID, ReporterID: userID}
+		report, err = cfg.dbQueries.GetChirpReportByReporter(r.Context(), existingParams)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			respondWithError(w, http.StatusInternalServerError, "failed to report chirp")
+			return
+		}
+
+		respondWithJson(w, http.StatusOK, newChirpReport(report))
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to report chirp")
+		return
+	}
+
+	respondWithJson(w, http.StatusCreated, newChirpReport(report))
+}
+
+func (cfg *apiConfig) getMyReportsHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	reports, err := cfg.dbQueries.GetChirpReportsByReporter(r.Context(), userID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get reports")
+		return
+	}
+
+	resp := []ChirpReport{}
+	for _, report := range reports {
+		resp = append(resp, newChirpReport(report))
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) getReportQueueHandler(w http.ResponseWriter, r *http.Request) {
+	_, ok := cfg.authenticateModerator(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset := parseLimitOffset(r)
+	queue, err := cfg.dbQueries.GetReportQueue(r.Context(), database.GetReportQueueParams{Limit: limit, Offset: offset})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get reports")
+		return
+	}
+
+	resp := []ReportedChirp{}
+	for _, item := range queue {
+		resp = append(resp, ReportedChirp{
+			ChirpID:          item.ChirpID,
+			UserID:           item.UserID.UUID,
+			Body:             item.Body,
+			ModerationStatus: item.ModerationStatus,
+			ReportCount:      item.ReportCount,
+			Reasons:          item.Reasons,
+		})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) moderateChirpHandler(w http.ResponseWriter, r *http.Request) {
+	moderator, ok := cfg.authenticateModerator(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id")
+		return
+	}
+
+	data := struct {
+		Action string `json:"action"`
+		Note   string `json:"note"`
+		// Until optionally ends a suspension.
+		Until *time.Time `json:"until"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	if !slices.Contains(moderationActions, data.Action) {
+		respondWithError(w, http.StatusBadRequest, "invalid moderation action")
+		return
+	}
+
+	until, err := suspensionEnd(data.Until)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to moderate chirp")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirp, err := qtx.GetSingleChirpForUpdate(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "no chirp found")
+		return
+	}
+
+	reportStatus := reportActioned
+	if data.Action == "dismiss" {
+		reportStatus = reportDismissed
+	}
+
+	// Resolve reports before acting on the chirp, and let each reporter know.
+	resolveParams := database.ResolveChirpReportsParams{ChirpID: uuid.NullUUID{UUID: chirpID, Valid: true}, Status: reportStatus}
+	resolved, err := qtx.ResolveChirpReports(r.Context(), resolveParams)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to moderate chirp")
+		return
+	}
+
+	notified := []uuid.UUID{}
+	for _, report := range resolved {
+		params := database.CreateReportNotificationParams{UserID: report.ReporterID, GroupKey: notifications.ReportGroupKey(report.ID)}
+		created, err := qtx.CreateReportNotification(r.Context(), params)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			respondWithError(w, http.StatusInternalServerError, "failed to moderate chirp")
+			return
+		}
+
+		if created > 0 {
+			notified = append(notified, report.ReporterID)
+		}
+	}
+
+	// A pending chirp that's dismissed is approved, and published the way it
+	// would have been had it passed moderation in the first place.
+	var published publishedChirp
+	approved := data.Action == "dismiss" && chirp.ModerationStatus == chirpPendingReview
+	switch data.Action {
+	case "dismiss":
+		if approved {
+			var author database.User
+			chirp, err = qtx.SetChirpModerationStatus(r.Context(), database.SetChirpModerationStatusParams{ID: chirpID, ModerationStatus: chirpApproved})
+			if err == nil {
+				author, err = qtx.GetUserByID(r.Context(), chirp.UserID.UUID)
+			}
+			if err == nil && chirp.Status == chirpPublished {
+				published, err = preparePublication(r.Context(), qtx, chirp, author)
+			}
+		}
+	case "hide":
+		_, err = qtx.SetChirpModerationStatus(r.Context(), database.SetChirpModerationStatusParams{ID: chirpID, ModerationStatus: chirpHidden})
+		if err == nil {
+			err = qtx.DeleteChirpPins(r.Context(), chirpID)
+		}
+	case "delete":
+		// Removals are kept apart from deletions by the author: they can't be
+		// restored and aren't purged.
+		_, err = qtx.RemoveChirp(r.Context(), database.RemoveChirpParams{ID: chirpID, RemovedBy: uuid.NullUUID{UUID: moderator.ID, Valid: true}})
+		if err == nil {
+			err = qtx.DeleteChirpPins(r.Context(), chirpID)
+		}
+	case "suspend":
+		// Recorded along with the suspension, like one made from the user's page.
+		err = suspendUser(r.Context(), qtx, moderator.ID, chirp.UserID.UUID, uuid.NullUUID{UUID: chirpID, Valid: true}, until, data.Note)
+	}
+
+	// Live streams drop hidden chirps the same way as deleted ones.
+	var event stream.Event
+	removed := data.Action == "hide" || data.Action == "delete"
+	if err == nil && removed {
+		event, err = recordChirpEvent(r.Context(), qtx, stream.ChirpDeleted, chirp)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to moderate chirp")
+		return
+	}
+
+	if data.Action != "suspend" {
+		actionParams := database.CreateModerationActionParams{
+			ModeratorID:  moderator.ID,
+			ChirpID:      uuid.NullUUID{UUID: chirpID, Valid: true},
+			TargetUserID: chirp.UserID,
+			Action:       data.Action,
+			Note:         data.Note,
+		}
+		_, err = qtx.CreateModerationAction(r.Context(), actionParams)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to moderate chirp")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to moderate chirp")
+		return
+	}
+
+	if removed {
+		cfg.publishChirpEvent(event)
+	}
+	if approved && chirp.Status == chirpPublished {
+		cfg.announceChirp(published)
+	}
+	cfg.publishNotifications(notified...)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,22 @@
+-- name: CreateAppeal :one
+INSERT INTO appeals (id, created_at, updated_at, user_id, suspended_at, message, status)
+VALUES (
+    gen_random_uuid(), NOW(), NOW(), $1, $2, $3, 'pending'
+)
+ON CONFLICT (user_id, suspended_at) DO NOTHING
+RETURNING *;
+
+-- name: GetPendingAppeals :many
+SELECT * FROM appeals
+WHERE status = 'pending'
+ORDER BY created_at
+LIMIT $1 OFFSET $2;
+
+-- name: GetAppealForUpdate :one
+SELECT * FROM appeals WHERE id = $1 FOR UPDATE;
+
+-- name: ResolveAppeal :one
+UPDATE appeals
+SET updated_at = NOW(), status = $2, reviewer_id = $3
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,16 @@
+-- name: CreateChirpEvent :one
+INSERT INTO chirp_events (created_at, kind, chirp_id, author_id, hashtags, unlisted)
+VALUES (NOW(), $1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetChirpEventsAfter :many
+SELECT * FROM chirp_events
+WHERE id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(max_events);
+
+-- name: DeleteChirpEventsBefore :exec
+DELETE FROM chirp_events WHERE created_at < $1;
+
+-- name: NotifyChirpEvent :exec
+SELECT pg_notify('chirp_events', sqlc.arg(payload)::TEXT);
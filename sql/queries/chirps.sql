@@ -1,20 +1,83 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, moderation_status, visibility)
 VALUES (
-    gen_random_uuid(), NOW(), NOW(), $1, $2
+    gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4
 )
 RETURNING *;
 
 -- name: GetAllChirps :many
-SELECT * FROM chirps ORDER BY created_at;
+SELECT chirps.* FROM chirps
+WHERE chirps.visibility <> 'unlisted'
+    AND can_view_chirp(chirps.id, sqlc.narg(viewer_id))
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.narg(viewer_id) AND mutes.muted_id = chirps.user_id
+    )
+ORDER BY chirps.created_at;
 
 -- name: GetAllChirpsByAuthor :many
-SELECT * FROM chirps WHERE user_id = $1 ORDER BY created_at;
+SELECT chirps.* FROM chirps
+WHERE chirps.user_id = sqlc.arg(author_id)
+    AND can_view_chirp(chirps.id, sqlc.narg(viewer_id))
+ORDER BY chirps.created_at;
 
 -- name: GetSingleChirp :one
-SELECT * FROM chirps WHERE id = $1;
+SELECT * FROM chirps
+WHERE id = $1 AND deleted_at IS NULL AND removed_at IS NULL;
 
 -- name: DeleteChirp :one
+UPDATE chirps SET deleted_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: RemoveChirp :one
+UPDATE chirps SET removed_at = NOW(), removed_by = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: GetDeletedChirpForUpdate :one
+SELECT * FROM chirps
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+FOR UPDATE;
+
+-- name: RestoreChirp :one
+UPDATE chirps SET deleted_at = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: PurgeDeletedChirps :execrows
 DELETE FROM chirps
+WHERE id IN (
+    SELECT id FROM chirps
+    WHERE deleted_at < sqlc.arg(deleted_before)
+    LIMIT sqlc.arg(batch_size)
+);
+
+-- name: GetSingleChirpForUpdate :one
+SELECT * FROM chirps
+WHERE id = $1 AND deleted_at IS NULL AND removed_at IS NULL
+FOR UPDATE;
+
+-- name: UpdateChirp :one
+UPDATE chirps
+SET updated_at = NOW(), body = $2, moderation_status = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: SetChirpModerationStatus :one
+UPDATE chirps
+SET updated_at = NOW(), moderation_status = $2
 WHERE id = $1
 RETURNING *;
+
+-- name: SetChirpVisibility :one
+UPDATE chirps
+SET updated_at = NOW(), visibility = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: CanViewChirp :one
+SELECT can_view_chirp(sqlc.arg(chirp_id)::UUID, sqlc.narg(viewer_id)::UUID) AS visible;
+
+-- name: InChirpAudience :one
+SELECT chirp_audience_includes(sqlc.arg(chirp_id)::UUID, sqlc.narg(viewer_id)::UUID) AS included;
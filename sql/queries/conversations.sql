@@ -0,0 +1,88 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (id, created_at, updated_at, pair_key)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1)
+RETURNING *;
+
+-- name: GetConversationByPairKey :one
+SELECT * FROM conversations WHERE pair_key = $1;
+
+-- name: TouchConversation :exec
+UPDATE conversations SET updated_at = NOW() WHERE id = $1;
+
+-- name: AddConversationMember :exec
+INSERT INTO conversation_members (conversation_id, user_id, joined_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (conversation_id, user_id) DO NOTHING;
+
+-- name: GetConversationForMember :one
+SELECT conversations.* FROM conversations
+INNER JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversations.id = sqlc.arg(conversation_id) AND conversation_members.user_id = sqlc.arg(user_id);
+
+-- name: GetConversations :many
+SELECT conversations.*, (
+    SELECT COUNT(*) FROM messages
+    WHERE messages.conversation_id = conversations.id
+        AND messages.sender_id <> sqlc.arg(user_id)
+        AND messages.created_at > COALESCE(conversation_members.last_read_at, '-infinity')
+        AND NOT EXISTS (
+            SELECT 1 FROM blocks
+            WHERE blocks.blocker_id = sqlc.arg(user_id) AND blocks.blocked_id = messages.sender_id
+        )
+) AS unread_count
+FROM conversations
+INNER JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversation_members.user_id = sqlc.arg(user_id)
+    AND (conversations.updated_at, conversations.id) < (sqlc.arg(before_updated_at)::TIMESTAMP, sqlc.arg(before_id)::UUID)
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetMembersForConversations :many
+SELECT conversation_members.conversation_id, users.id AS user_id, users.handle FROM conversation_members
+INNER JOIN users ON users.id = conversation_members.user_id
+WHERE conversation_members.conversation_id = ANY(sqlc.arg(conversation_ids)::UUID[])
+ORDER BY conversation_members.joined_at, users.handle;
+
+-- name: GetMessageRecipients :many
+SELECT conversation_members.user_id FROM conversation_members
+WHERE conversation_members.conversation_id = sqlc.arg(conversation_id)
+    AND conversation_members.user_id <> sqlc.arg(sender_id)
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE blocks.blocker_id = conversation_members.user_id AND blocks.blocked_id = sqlc.arg(sender_id)
+    );
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_members SET last_read_at = NOW()
+WHERE conversation_id = $1 AND user_id = $2;
+
+-- name: CreateMessage :one
+INSERT INTO messages (id, created_at, conversation_id, sender_id, body)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3)
+RETURNING *;
+
+-- name: GetMessage :one
+SELECT * FROM messages WHERE id = $1;
+
+-- name: GetMessages :many
+SELECT * FROM messages
+WHERE conversation_id = sqlc.arg(conversation_id)
+    AND (created_at, id) < (sqlc.arg(before_created_at)::TIMESTAMP, sqlc.arg(before_id)::UUID)
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE blocks.blocker_id = sqlc.arg(viewer_id) AND blocks.blocked_id = messages.sender_id
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: CountUnreadMessages :one
+SELECT COUNT(*) FROM messages
+INNER JOIN conversation_members ON conversation_members.conversation_id = messages.conversation_id
+WHERE messages.conversation_id = sqlc.arg(conversation_id)
+    AND conversation_members.user_id = sqlc.arg(user_id)
+    AND messages.sender_id <> sqlc.arg(user_id)
+    AND messages.created_at > COALESCE(conversation_members.last_read_at, '-infinity')
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE blocks.blocker_id = sqlc.arg(user_id) AND blocks.blocked_id = messages.sender_id
+    );
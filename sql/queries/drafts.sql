@@ -0,0 +1,52 @@
+-- name: CreateDraft :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, moderation_status, visibility, status, publish_at)
+VALUES (
+    gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetDrafts :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id)
+    AND status <> 'published'
+    AND (created_at, id) < (sqlc.arg(before_created_at)::TIMESTAMP, sqlc.arg(before_id)::UUID)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetDraft :one
+SELECT * FROM chirps
+WHERE id = $1 AND user_id = $2 AND status <> 'published';
+
+-- name: GetDraftForUpdate :one
+SELECT * FROM chirps
+WHERE id = $1 AND user_id = $2 AND status <> 'published'
+FOR UPDATE;
+
+-- name: UpdateDraft :one
+UPDATE chirps
+SET updated_at = NOW(), body = $2, moderation_status = $3, visibility = $4, status = $5, publish_at = $6
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteDraft :execrows
+DELETE FROM chirps
+WHERE id = $1 AND user_id = $2 AND status <> 'published';
+
+-- name: PublishChirp :one
+UPDATE chirps
+SET created_at = NOW(), updated_at = NOW(), status = 'published', publish_at = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: GetDueChirps :many
+SELECT * FROM chirps
+WHERE status = 'scheduled' AND publish_at <= NOW()
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = chirps.user_id
+            AND users.suspended_at IS NOT NULL
+            AND (users.suspended_until IS NULL OR users.suspended_until > NOW())
+    )
+ORDER BY publish_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
@@ -0,0 +1,46 @@
+-- name: CreateChirpEntity :exec
+INSERT INTO chirp_entities (chirp_id, kind, start_offset, end_offset, text, value, user_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: DeleteChirpEntities :exec
+DELETE FROM chirp_entities WHERE chirp_id = $1;
+
+-- name: GetEntitiesForChirps :many
+SELECT * FROM chirp_entities
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::UUID[])
+ORDER BY chirp_id, start_offset;
+
+-- name: GetChirpsByHashtag :many
+SELECT chirps.* FROM chirps
+WHERE EXISTS (
+        SELECT 1 FROM chirp_entities
+        WHERE chirp_entities.chirp_id = chirps.id
+            AND chirp_entities.kind = 'hashtag'
+            AND chirp_entities.value = sqlc.arg(tag)
+    )
+    AND (chirps.created_at, chirps.id) < (sqlc.arg(before_created_at)::TIMESTAMP, sqlc.arg(before_id)::UUID)
+    AND chirps.visibility <> 'unlisted'
+    AND can_view_chirp(chirps.id, sqlc.narg(viewer_id))
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.narg(viewer_id) AND mutes.muted_id = chirps.user_id
+    )
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetMentions :many
+SELECT chirps.* FROM chirps
+WHERE EXISTS (
+        SELECT 1 FROM chirp_entities
+        WHERE chirp_entities.chirp_id = chirps.id
+            AND chirp_entities.kind = 'mention'
+            AND chirp_entities.user_id = sqlc.arg(user_id)
+    )
+    AND (chirps.created_at, chirps.id) < (sqlc.arg(before_created_at)::TIMESTAMP, sqlc.arg(before_id)::UUID)
+    AND can_view_chirp(chirps.id, sqlc.arg(user_id))
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.arg(user_id) AND mutes.muted_id = chirps.user_id
+    )
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,92 @@
+-- name: CreateFollow :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (follower_id, followee_id) DO NOTHING;
+
+-- name: DeleteFollow :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: GetFollowers :many
+SELECT follower_id AS user_id, created_at FROM follows
+WHERE followee_id = sqlc.arg(user_id)
+    AND (created_at, follower_id) < (sqlc.arg(before_created_at)::TIMESTAMP, sqlc.arg(before_id)::UUID)
+ORDER BY created_at DESC, follower_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetFollowing :many
+SELECT followee_id AS user_id, created_at FROM follows
+WHERE follower_id = sqlc.arg(user_id)
+    AND (created_at, followee_id) < (sqlc.arg(before_created_at)::TIMESTAMP, sqlc.arg(before_id)::UUID)
+ORDER BY created_at DESC, followee_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetFollowCounts :one
+SELECT
+    (SELECT COUNT(*) FROM follows WHERE followee_id = sqlc.arg(user_id)) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follower_id = sqlc.arg(user_id)) AS following_count;
+
+-- name: GetHomeTimeline :many
+SELECT chirps.* FROM (
+    (
+        SELECT timeline_entries.chirp_id AS id FROM timeline_entries
+        WHERE timeline_entries.user_id = sqlc.arg(user_id)
+            AND (timeline_entries.created_at, timeline_entries.chirp_id) < (sqlc.arg(before_created_at)::TIMESTAMP, sqlc.arg(before_id)::UUID)
+            AND can_view_chirp(timeline_entries.chirp_id, sqlc.arg(user_id))
+            AND NOT EXISTS (
+                SELECT 1 FROM mutes
+                WHERE mutes.muter_id = sqlc.arg(user_id) AND mutes.muted_id = timeline_entries.author_id
+            )
+        ORDER BY timeline_entries.created_at DESC, timeline_entries.chirp_id DESC
+        LIMIT sqlc.arg(page_size)
+    )
+    UNION
+    (
+        SELECT c.id FROM follows
+        INNER JOIN users ON users.id = follows.followee_id
+        CROSS JOIN LATERAL (
+            SELECT chirps.id, chirps.created_at FROM chirps
+            WHERE chirps.user_id = follows.followee_id
+                AND (chirps.created_at, chirps.id) < (sqlc.arg(before_created_at)::TIMESTAMP, sqlc.arg(before_id)::UUID)
+                AND can_view_chirp(chirps.id, sqlc.arg(user_id))
+            ORDER BY chirps.created_at DESC, chirps.id DESC
+            LIMIT sqlc.arg(page_size)
+        ) AS c
+        WHERE follows.follower_id = sqlc.arg(user_id)
+            AND users.follower_count >= sqlc.arg(heavy_follower_count)
+            AND NOT EXISTS (
+                SELECT 1 FROM mutes
+                WHERE mutes.muter_id = sqlc.arg(user_id) AND mutes.muted_id = users.id
+            )
+    )
+) AS page
+INNER JOIN chirps ON chirps.id = page.id
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetFollowingIDs :many
+SELECT followee_id FROM follows WHERE follower_id = $1;
+
+-- name: IsFollowing :one
+SELECT EXISTS (
+    SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2
+);
+
+-- name: CreateFollowRequest :execrows
+INSERT INTO follow_requests (requester_id, target_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (requester_id, target_id) DO NOTHING;
+
+-- name: DeleteFollowRequest :execrows
+DELETE FROM follow_requests
+WHERE requester_id = $1 AND target_id = $2;
+
+-- name: GetFollowRequests :many
+SELECT requester_id AS user_id, created_at FROM follow_requests
+WHERE target_id = sqlc.arg(user_id)
+    AND (created_at, requester_id) < (sqlc.arg(before_created_at)::TIMESTAMP, sqlc.arg(before_id)::UUID)
+ORDER BY created_at DESC, requester_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetFollowRequesterIDs :many
+SELECT requester_id FROM follow_requests WHERE target_id = $1;
@@ -0,0 +1,13 @@
+-- name: CreateHandleRedirect :exec
+INSERT INTO handle_redirects (old_handle, user_id, created_at, expires_at)
+VALUES (lower(sqlc.arg(old_handle)), sqlc.arg(user_id), NOW(), sqlc.arg(expires_at))
+ON CONFLICT (old_handle) DO UPDATE
+SET user_id = EXCLUDED.user_id, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at;
+
+-- name: GetHandleRedirect :one
+SELECT * FROM handle_redirects
+WHERE old_handle = lower(sqlc.arg(handle)) AND expires_at > NOW();
+
+-- name: DeleteHandleRedirect :exec
+DELETE FROM handle_redirects
+WHERE old_handle = lower(sqlc.arg(handle));
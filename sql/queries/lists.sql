@@ -0,0 +1,62 @@
+-- name: CreateList :one
+INSERT INTO lists (id, created_at, updated_at, owner_id, name, description, is_private)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetList :one
+SELECT * FROM lists WHERE id = $1;
+
+-- name: GetListsByOwner :many
+SELECT * FROM lists
+WHERE owner_id = sqlc.arg(owner_id)
+    AND (NOT is_private OR sqlc.arg(include_private)::BOOLEAN)
+    AND (created_at, id) < (sqlc.arg(before_created_at)::TIMESTAMP, sqlc.arg(before_id)::UUID)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: UpdateList :one
+UPDATE lists SET updated_at = NOW(), name = $2, description = $3, is_private = $4
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteList :exec
+DELETE FROM lists WHERE id = $1;
+
+-- name: AddListMember :execrows
+INSERT INTO list_members (list_id, user_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (list_id, user_id) DO NOTHING;
+
+-- name: RemoveListMember :execrows
+DELETE FROM list_members
+WHERE list_id = $1 AND user_id = $2;
+
+-- name: CountListMembers :one
+SELECT COUNT(*) FROM list_members WHERE list_id = $1;
+
+-- name: GetListMembers :many
+SELECT user_id, created_at FROM list_members
+WHERE list_id = sqlc.arg(list_id)
+    AND (created_at, user_id) < (sqlc.arg(before_created_at)::TIMESTAMP, sqlc.arg(before_id)::UUID)
+ORDER BY created_at DESC, user_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetListTimeline :many
+SELECT chirps.* FROM chirps
+INNER JOIN list_members ON list_members.user_id = chirps.user_id
+WHERE list_members.list_id = sqlc.arg(list_id)
+    AND (chirps.created_at, chirps.id) < (sqlc.arg(before_created_at)::TIMESTAMP, sqlc.arg(before_id)::UUID)
+    AND can_view_chirp(chirps.id, sqlc.narg(viewer_id))
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.narg(viewer_id) AND mutes.muted_id = chirps.user_id
+    )
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: RemoveFromOwnersLists :exec
+DELETE FROM list_members
+USING lists
+WHERE lists.id = list_members.list_id
+    AND lists.owner_id = sqlc.arg(owner_id)
+    AND list_members.user_id = sqlc.arg(user_id);
@@ -0,0 +1,22 @@
+-- name: CreateMediaFile :one
+INSERT INTO media_files (id, created_at, user_id, content_type, width, height, size_bytes, blob_key, thumbnail_key)
+VALUES (
+    $1, NOW(), $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: GetMediaFile :one
+SELECT * FROM media_files WHERE id = $1;
+
+-- name: AttachChirpMedia :exec
+INSERT INTO chirp_media (chirp_id, media_id, position)
+VALUES ($1, $2, $3);
+
+-- name: GetChirpMedia :many
+SELECT media_files.* FROM media_files
+INNER JOIN chirp_media ON chirp_media.media_id = media_files.id
+WHERE chirp_media.chirp_id = $1
+ORDER BY chirp_media.position;
+
+-- name: DeleteChirpMedia :exec
+DELETE FROM chirp_media WHERE chirp_id = $1;
@@ -0,0 +1,5 @@
+-- name: CreateModerationDecision :exec
+INSERT INTO moderation_decisions (id, created_at, user_id, chirp_id, body, filter, action, reason, matches)
+VALUES (
+    gen_random_uuid(), NOW(), $1, $2, $3, $4, $5, $6, $7
+);
@@ -0,0 +1,85 @@
+-- name: CreateNotification :execrows
+INSERT INTO notifications (id, created_at, user_id, actor_id, kind, chirp_id, group_key)
+SELECT gen_random_uuid(), NOW(), sqlc.arg(user_id)::UUID, sqlc.arg(actor_id)::UUID, sqlc.arg(kind)::TEXT, sqlc.narg(chirp_id)::UUID, sqlc.arg(group_key)::TEXT
+WHERE sqlc.arg(user_id)::UUID <> sqlc.arg(actor_id)::UUID
+    AND COALESCE((
+        SELECT enabled FROM notification_preferences
+        WHERE notification_preferences.user_id = sqlc.arg(user_id)::UUID
+            AND notification_preferences.kind = sqlc.arg(kind)::TEXT
+    ), TRUE)
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = sqlc.arg(actor_id)::UUID AND users.shadowbanned_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.arg(user_id)::UUID AND mutes.muted_id = sqlc.arg(actor_id)::UUID
+    )
+ON CONFLICT (user_id, group_key, actor_id) DO NOTHING;
+
+-- name: CreateReportNotification :execrows
+INSERT INTO notifications (id, created_at, user_id, actor_id, kind, chirp_id, group_key)
+SELECT gen_random_uuid(), NOW(), sqlc.arg(user_id)::UUID, sqlc.arg(user_id)::UUID, 'report_resolved', NULL, sqlc.arg(group_key)::TEXT
+WHERE COALESCE((
+        SELECT enabled FROM notification_preferences
+        WHERE notification_preferences.user_id = sqlc.arg(user_id)::UUID
+            AND notification_preferences.kind = 'report_resolved'
+    ), TRUE)
+ON CONFLICT (user_id, group_key, actor_id) DO NOTHING;
+
+-- name: GetNotifications :many
+SELECT notifications.*, users.handle AS actor_handle FROM notifications
+INNER JOIN users ON users.id = notifications.actor_id
+WHERE notifications.user_id = sqlc.arg(user_id)
+    AND (notifications.created_at, notifications.id) < (sqlc.arg(before_created_at)::TIMESTAMP, sqlc.arg(before_id)::UUID)
+    AND (NOT sqlc.arg(unread_only)::BOOLEAN OR notifications.read_at IS NULL)
+    AND NOT EXISTS (
+        SELECT 1 FROM chirps
+        WHERE chirps.id = notifications.chirp_id
+            AND (chirps.deleted_at IS NOT NULL OR chirps.removed_at IS NOT NULL)
+    )
+    AND (notifications.chirp_id IS NULL OR can_view_chirp(notifications.chirp_id, sqlc.arg(user_id)))
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = sqlc.arg(user_id) AND blocks.blocked_id = users.id)
+            OR (blocks.blocker_id = users.id AND blocks.blocked_id = sqlc.arg(user_id))
+    )
+ORDER BY notifications.created_at DESC, notifications.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+INNER JOIN users ON users.id = notifications.actor_id
+WHERE notifications.user_id = $1 AND notifications.read_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM chirps
+        WHERE chirps.id = notifications.chirp_id
+            AND (chirps.deleted_at IS NOT NULL OR chirps.removed_at IS NOT NULL)
+    )
+    AND (notifications.chirp_id IS NULL OR can_view_chirp(notifications.chirp_id, $1))
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = $1 AND blocks.blocked_id = users.id)
+            OR (blocks.blocker_id = users.id AND blocks.blocked_id = $1)
+    );
+
+-- name: MarkNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = sqlc.arg(user_id)
+    AND read_at IS NULL
+    AND id = ANY(sqlc.arg(ids)::UUID[]);
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: GetNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE user_id = $1;
+
+-- name: SetNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, kind, enabled)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, kind) DO UPDATE SET enabled = EXCLUDED.enabled;
@@ -0,0 +1,36 @@
+-- name: LockPinnedChirps :exec
+SELECT id FROM users WHERE id = $1 FOR UPDATE;
+
+-- name: PinChirp :execrows
+INSERT INTO pinned_chirps (user_id, chirp_id, position, created_at)
+VALUES (
+    sqlc.arg(user_id),
+    sqlc.arg(chirp_id),
+    (SELECT COALESCE(MAX(position) + 1, 0) FROM pinned_chirps WHERE user_id = sqlc.arg(user_id)),
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: UnpinChirp :execrows
+DELETE FROM pinned_chirps
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: DeleteChirpPins :exec
+DELETE FROM pinned_chirps WHERE chirp_id = $1;
+
+-- name: GetPinnedChirpIDs :many
+SELECT chirp_id FROM pinned_chirps
+WHERE user_id = $1
+ORDER BY position;
+
+-- name: SetPinPosition :exec
+UPDATE pinned_chirps SET position = $3
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetPinnedChirps :many
+SELECT chirps.* FROM pinned_chirps
+INNER JOIN chirps ON chirps.id = pinned_chirps.chirp_id
+WHERE pinned_chirps.user_id = sqlc.arg(user_id)
+    AND can_view_chirp(chirps.id, sqlc.narg(viewer_id))
+ORDER BY pinned_chirps.position
+LIMIT sqlc.arg(page_size);
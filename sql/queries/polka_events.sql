@@ -0,0 +1,26 @@
+-- name: CreatePolkaEvent :execrows
+INSERT INTO polka_events (id, event_id, received_at, updated_at, event, payload, status)
+VALUES (
+    gen_random_uuid(), $1, NOW(), NOW(), $2, $3, 'pending'
+)
+ON CONFLICT (event_id) DO NOTHING;
+
+-- name: GetPolkaEventForUpdate :one
+SELECT * FROM polka_events WHERE event_id = $1 FOR UPDATE;
+
+-- name: MarkPolkaEventProcessed :exec
+UPDATE polka_events
+SET updated_at = NOW(), status = 'processed', attempts = attempts + 1, last_error = '', processed_at = NOW()
+WHERE event_id = $1;
+
+-- name: MarkPolkaEventFailed :exec
+UPDATE polka_events
+SET updated_at = NOW(), status = 'failed', attempts = attempts + 1, last_error = $2
+WHERE event_id = $1;
+
+-- name: GetFailedPolkaEvents :many
+SELECT * FROM polka_events
+WHERE status = 'failed'
+    AND (received_at, id) < (sqlc.arg(before_received_at)::TIMESTAMP, sqlc.arg(before_id)::UUID)
+ORDER BY received_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
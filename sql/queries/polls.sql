@@ -0,0 +1,35 @@
+-- name: CreatePoll :exec
+INSERT INTO polls (chirp_id, created_at, closes_at, multiple_choice)
+VALUES ($1, NOW(), $2, $3);
+
+-- name: CreatePollOption :exec
+INSERT INTO poll_options (chirp_id, position, text)
+VALUES ($1, $2, $3);
+
+-- name: GetPoll :one
+SELECT * FROM polls WHERE chirp_id = $1;
+
+-- name: GetPollsForChirps :many
+SELECT * FROM polls
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::UUID[]);
+
+-- name: GetPollOptionsForChirps :many
+SELECT * FROM poll_options
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::UUID[])
+ORDER BY chirp_id, position;
+
+-- name: GetPollVotesByUser :many
+SELECT * FROM poll_votes
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::UUID[]) AND user_id = sqlc.arg(user_id);
+
+-- name: CreatePollVote :execrows
+INSERT INTO poll_votes (chirp_id, user_id, created_at, positions)
+VALUES ($1, $2, NOW(), $3)
+ON CONFLICT (chirp_id, user_id) DO NOTHING;
+
+-- name: CountPollVote :exec
+UPDATE poll_options SET vote_count = vote_count + 1
+WHERE chirp_id = sqlc.arg(chirp_id) AND position = ANY(sqlc.arg(positions)::INTEGER[]);
+
+-- name: IncrementPollVoterCount :exec
+UPDATE polls SET voter_count = voter_count + 1 WHERE chirp_id = $1;
@@ -0,0 +1,46 @@
+-- name: CreateChirpReport :one
+INSERT INTO chirp_reports (id, created_at, updated_at, chirp_id, reporter_id, reason, details, status)
+VALUES (
+    gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, 'open'
+)
+ON CONFLICT (chirp_id, reporter_id) DO NOTHING
+RETURNING *;
+
+-- name: GetChirpReportByReporter :one
+SELECT * FROM chirp_reports
+WHERE chirp_id = $1 AND reporter_id = $2;
+
+-- name: GetChirpReportsByReporter :many
+SELECT * FROM chirp_reports
+WHERE reporter_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetReportQueue :many
+SELECT
+    chirps.id AS chirp_id,
+    chirps.user_id,
+    chirps.body,
+    chirps.moderation_status,
+    COUNT(chirp_reports.id) AS report_count,
+    COALESCE(array_agg(DISTINCT chirp_reports.reason) FILTER (WHERE chirp_reports.id IS NOT NULL), '{}')::TEXT[] AS reasons
+FROM chirps
+LEFT JOIN chirp_reports ON chirp_reports.chirp_id = chirps.id AND chirp_reports.status = 'open'
+WHERE (chirps.moderation_status = 'pending_review' OR chirp_reports.id IS NOT NULL)
+    AND chirps.deleted_at IS NULL
+    AND chirps.removed_at IS NULL
+GROUP BY chirps.id
+ORDER BY report_count DESC, chirps.created_at
+LIMIT $1 OFFSET $2;
+
+-- name: ResolveChirpReports :many
+UPDATE chirp_reports
+SET updated_at = NOW(), status = $2
+WHERE chirp_id = $1 AND status = 'open'
+RETURNING id, reporter_id;
+
+-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (id, created_at, moderator_id, chirp_id, target_user_id, action, note)
+VALUES (
+    gen_random_uuid(), NOW(), $1, $2, $3, $4, $5
+)
+RETURNING *;
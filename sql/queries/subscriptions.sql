@@ -0,0 +1,46 @@
+-- name: CreateSubscription :one
+INSERT INTO subscriptions (id, created_at, updated_at, user_id, plan, status, period_start, period_end)
+VALUES (
+    gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetCurrentSubscription :one
+SELECT * FROM subscriptions
+WHERE user_id = $1 AND status IN ('active', 'canceled')
+ORDER BY period_end DESC
+LIMIT 1
+FOR UPDATE;
+
+-- name: SetSubscriptionStatus :exec
+UPDATE subscriptions
+SET updated_at = NOW(), status = $2
+WHERE id = $1;
+
+-- name: EndSubscription :exec
+UPDATE subscriptions
+SET updated_at = NOW(), status = $2, period_end = LEAST(period_end, NOW())
+WHERE id = $1;
+
+-- name: ExpireSubscriptions :many
+UPDATE subscriptions
+SET updated_at = NOW(), status = 'expired'
+WHERE status IN ('active', 'canceled') AND period_end <= NOW()
+RETURNING user_id;
+
+-- name: SyncChirpyRed :exec
+UPDATE users
+SET is_chirpy_red = EXISTS (
+    SELECT 1 FROM subscriptions
+    WHERE subscriptions.user_id = users.id
+        AND subscriptions.status IN ('active', 'canceled')
+        AND subscriptions.period_end > NOW()
+)
+WHERE id = $1;
+
+-- name: GetSubscriptions :many
+SELECT * FROM subscriptions
+WHERE user_id = sqlc.arg(user_id)
+    AND (created_at, id) < (sqlc.arg(before_created_at)::TIMESTAMP, sqlc.arg(before_id)::UUID)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
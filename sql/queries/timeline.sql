@@ -0,0 +1,82 @@
+-- name: FanOutChirp :execrows
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT follows.follower_id, chirps.id, follows.followee_id, chirps.created_at FROM chirps
+INNER JOIN follows ON follows.followee_id = chirps.user_id
+WHERE chirps.id = $1
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: AddChirpToOwnTimeline :exec
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT chirps.user_id, chirps.id, chirps.user_id, chirps.created_at FROM chirps
+WHERE chirps.id = $1
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: BackfillTimelineFromAuthor :execrows
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT sqlc.arg(user_id)::UUID, chirps.id, sqlc.arg(author_id)::UUID, chirps.created_at FROM chirps
+WHERE chirps.user_id = sqlc.arg(author_id)
+    AND chirps.status = 'published'
+    AND chirps.deleted_at IS NULL
+    AND chirps.removed_at IS NULL
+ORDER BY chirps.created_at DESC
+LIMIT sqlc.arg(backfill_size)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: DeleteTimelineEntriesFromAuthor :exec
+DELETE FROM timeline_entries
+WHERE user_id = $1 AND author_id = $2;
+
+-- name: DeleteTimeline :exec
+DELETE FROM timeline_entries
+WHERE user_id = $1;
+
+-- name: RebuildTimeline :execrows
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT follows.follower_id, c.id, follows.followee_id, c.created_at FROM follows
+INNER JOIN users ON users.id = follows.followee_id
+CROSS JOIN LATERAL (
+    SELECT chirps.id, chirps.created_at FROM chirps
+    WHERE chirps.user_id = follows.followee_id
+        AND chirps.status = 'published'
+        AND chirps.deleted_at IS NULL
+        AND chirps.removed_at IS NULL
+    ORDER BY chirps.created_at DESC
+    LIMIT sqlc.arg(backfill_size)
+) AS c
+WHERE follows.follower_id = sqlc.arg(user_id)
+    AND users.follower_count < sqlc.arg(heavy_follower_count)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: CreateFanoutJob :exec
+INSERT INTO fanout_jobs (chirp_id, created_at, run_at)
+VALUES (
+    $1, NOW(), NOW()
+)
+ON CONFLICT (chirp_id) DO UPDATE SET run_at = NOW();
+
+-- name: CreateAuthorFanoutJobs :execrows
+INSERT INTO fanout_jobs (chirp_id, created_at, run_at)
+SELECT chirps.id, NOW(), NOW() FROM chirps
+WHERE chirps.user_id = sqlc.arg(author_id)
+    AND chirps.status = 'published'
+    AND chirps.deleted_at IS NULL
+    AND chirps.removed_at IS NULL
+ORDER BY chirps.created_at DESC
+LIMIT sqlc.arg(backfill_size)
+ON CONFLICT (chirp_id) DO UPDATE SET run_at = NOW();
+
+-- name: ClaimFanoutJob :one
+SELECT chirp_id FROM fanout_jobs
+WHERE run_at <= NOW()
+ORDER BY run_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: DeleteFanoutJob :exec
+DELETE FROM fanout_jobs
+WHERE chirp_id = $1;
+
+-- name: RetryFanoutJob :exec
+UPDATE fanout_jobs
+SET attempts = attempts + 1, run_at = NOW() + LEAST(attempts + 1, 60) * INTERVAL '1 minute'
+WHERE chirp_id = $1;
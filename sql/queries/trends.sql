@@ -0,0 +1,27 @@
+-- name: GetHashtagUsage :many
+SELECT chirp_entities.value AS tag, users.locale,
+    COUNT(DISTINCT chirps.id) FILTER (WHERE chirps.created_at >= sqlc.arg(window_start)::TIMESTAMP) AS recent_count,
+    COUNT(DISTINCT chirps.id) FILTER (WHERE chirps.created_at < sqlc.arg(window_start)::TIMESTAMP) AS baseline_count,
+    COUNT(DISTINCT chirps.user_id) FILTER (WHERE chirps.created_at >= sqlc.arg(window_start)::TIMESTAMP) AS author_count
+FROM chirp_entities
+INNER JOIN chirps ON chirps.id = chirp_entities.chirp_id
+INNER JOIN users ON users.id = chirps.user_id
+WHERE chirp_entities.kind = 'hashtag'
+    AND chirps.created_at >= sqlc.arg(baseline_start)::TIMESTAMP
+    AND chirps.visibility = 'public'
+    AND can_view_chirp(chirps.id, NULL::UUID)
+    AND NOT EXISTS (
+        SELECT 1 FROM trend_denylist WHERE trend_denylist.tag = chirp_entities.value
+    )
+GROUP BY chirp_entities.value, users.locale;
+
+-- name: AddTrendDenylist :exec
+INSERT INTO trend_denylist (tag, created_at, created_by)
+VALUES ($1, NOW(), $2)
+ON CONFLICT (tag) DO NOTHING;
+
+-- name: RemoveTrendDenylist :execrows
+DELETE FROM trend_denylist WHERE tag = $1;
+
+-- name: GetTrendDenylist :many
+SELECT * FROM trend_denylist ORDER BY tag;
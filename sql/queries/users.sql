@@ -1,21 +1,62 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
-    gen_random_uuid(), NOW(), NOW(), $1, $2
+    gen_random_uuid(), NOW(), NOW(), $1, $2, $3
 )
 RETURNING *;
 
 -- name: GetUser :one
 SELECT * FROM users WHERE email = $1;
 
+-- name: GetUserByHandle :one
+SELECT * FROM users WHERE lower(handle) = lower(sqlc.arg(handle));
+
 -- name: UpdateUser :one
 UPDATE users
 SET updated_at = NOW(), email = $2, hashed_password = $3
 WHERE id = $1
 RETURNING *;
 
--- name: UpgradeUserToChirpyRed :one
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = $1;
+
+-- name: SuspendUser :one
+UPDATE users
+SET updated_at = NOW(), suspended_at = NOW(), suspended_until = $2, suspension_reason = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: LiftUserSuspension :one
+UPDATE users
+SET updated_at = NOW(), suspended_at = NULL, suspended_until = NULL, suspension_reason = ''
+WHERE id = $1
+RETURNING *;
+
+-- name: ShadowbanUser :one
+UPDATE users
+SET updated_at = NOW(), shadowbanned_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: LiftUserShadowban :one
+UPDATE users
+SET updated_at = NOW(), shadowbanned_at = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: IncrementFollowerCount :exec
+UPDATE users
+SET follower_count = follower_count + 1
+WHERE id = $1;
+
+-- name: DecrementFollowerCount :one
+UPDATE users
+SET follower_count = follower_count - 1
+WHERE id = $1
+RETURNING follower_count;
+
+-- name: UpdateUserProfile :one
 UPDATE users
-SET updated_at = NOW(), is_chirpy_red = true
+SET updated_at = NOW(), handle = $2, display_name = $3, bio = $4, avatar_url = $5, locale = $6, dm_policy = $7, is_private = $8
 WHERE id = $1
 RETURNING *;
@@ -0,0 +1,14 @@
+-- name: CreateWSTicket :one
+INSERT INTO ws_tickets (ticket, created_at, user_id, expires_at)
+VALUES (
+    $1, NOW(), $2, NOW() + INTERVAL '30 seconds'
+)
+RETURNING *;
+
+-- name: RedeemWSTicket :one
+DELETE FROM ws_tickets
+WHERE ticket = $1 AND expires_at > NOW()
+RETURNING user_id;
+
+-- name: DeleteExpiredWSTickets :exec
+DELETE FROM ws_tickets WHERE expires_at <= NOW();
@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN moderation_status TEXT NOT NULL DEFAULT 'approved';
+
+CREATE TABLE moderation_decisions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    filter TEXT NOT NULL,
+    action TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    matches TEXT[] NOT NULL DEFAULT '{}'
+);
+
+-- +goose Down
+DROP TABLE moderation_decisions;
+
+ALTER TABLE chirps
+DROP COLUMN moderation_status;
@@ -0,0 +1,36 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN is_moderator BOOLEAN NOT NULL DEFAULT false,
+ADD COLUMN suspended_at TIMESTAMP;
+
+CREATE TABLE chirp_reports (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open',
+    UNIQUE (chirp_id, reporter_id)
+);
+
+CREATE INDEX chirp_reports_status_idx ON chirp_reports (status, chirp_id);
+
+CREATE TABLE moderation_actions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    moderator_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID,
+    target_user_id UUID,
+    action TEXT NOT NULL,
+    note TEXT NOT NULL DEFAULT ''
+);
+
+-- +goose Down
+DROP TABLE moderation_actions;
+DROP TABLE chirp_reports;
+
+ALTER TABLE users
+DROP COLUMN suspended_at,
+DROP COLUMN is_moderator;
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN suspended_until TIMESTAMP,
+ADD COLUMN suspension_reason TEXT NOT NULL DEFAULT '',
+ADD COLUMN shadowbanned_at TIMESTAMP;
+
+CREATE TABLE appeals (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    suspended_at TIMESTAMP NOT NULL,
+    message TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    reviewer_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE (user_id, suspended_at)
+);
+
+-- +goose Down
+DROP TABLE appeals;
+
+ALTER TABLE users
+DROP COLUMN shadowbanned_at,
+DROP COLUMN suspension_reason,
+DROP COLUMN suspended_until;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE chirp_entities (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    value TEXT NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    PRIMARY KEY (chirp_id, start_offset)
+);
+
+CREATE INDEX chirp_entities_hashtag_idx ON chirp_entities (value) WHERE kind = 'hashtag';
+CREATE INDEX chirp_entities_mention_idx ON chirp_entities (user_id) WHERE kind = 'mention';
+
+-- +goose Down
+DROP TABLE chirp_entities;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN locale TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX chirps_created_at_idx ON chirps (created_at);
+
+CREATE TABLE trend_denylist (
+    tag TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- +goose Down
+DROP TABLE trend_denylist;
+DROP INDEX chirps_created_at_idx;
+ALTER TABLE users DROP COLUMN locale;
@@ -0,0 +1,55 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public';
+ALTER TABLE chirp_events ADD COLUMN unlisted BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- can_view_chirp is the one place that decides whether a viewer may see a
+-- chirp. A NULL viewer is an anonymous request. Unlisted chirps are visible
+-- here; public lists and search leave them out separately.
+-- +goose StatementBegin
+CREATE FUNCTION can_view_chirp(chirp_id UUID, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE SQL STABLE AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM chirps
+        INNER JOIN users ON users.id = chirps.user_id
+        WHERE chirps.id = can_view_chirp.chirp_id
+            AND chirps.moderation_status = 'approved'
+            AND (users.suspended_at IS NULL OR users.suspended_until <= NOW())
+            AND (
+                users.id = can_view_chirp.viewer_id
+                OR (
+                    users.shadowbanned_at IS NULL
+                    AND NOT EXISTS (
+                        SELECT 1 FROM blocks
+                        WHERE (blocks.blocker_id = can_view_chirp.viewer_id AND blocks.blocked_id = users.id)
+                            OR (blocks.blocker_id = users.id AND blocks.blocked_id = can_view_chirp.viewer_id)
+                    )
+                    AND (
+                        NOT users.is_private
+                        OR EXISTS (
+                            SELECT 1 FROM follows
+                            WHERE follows.follower_id = can_view_chirp.viewer_id AND follows.followee_id = users.id
+                        )
+                    )
+                    AND CASE chirps.visibility
+                        WHEN 'followers' THEN EXISTS (
+                            SELECT 1 FROM follows
+                            WHERE follows.follower_id = can_view_chirp.viewer_id AND follows.followee_id = users.id
+                        )
+                        WHEN 'mentioned' THEN EXISTS (
+                            SELECT 1 FROM chirp_entities
+                            WHERE chirp_entities.chirp_id = chirps.id
+                                AND chirp_entities.kind = 'mention'
+                                AND chirp_entities.user_id = can_view_chirp.viewer_id
+                        )
+                        ELSE TRUE
+                    END
+                )
+            )
+    )
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION can_view_chirp(UUID, UUID);
+ALTER TABLE chirp_events DROP COLUMN unlisted;
+ALTER TABLE chirps DROP COLUMN visibility;
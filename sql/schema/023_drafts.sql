@@ -0,0 +1,102 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN status TEXT NOT NULL DEFAULT 'published';
+ALTER TABLE chirps ADD COLUMN publish_at TIMESTAMP;
+
+CREATE INDEX chirps_drafts_idx ON chirps (user_id, created_at DESC, id DESC) WHERE status <> 'published';
+CREATE INDEX chirps_scheduled_idx ON chirps (publish_at) WHERE status = 'scheduled';
+
+-- Drafts and scheduled chirps are hidden until they're published.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION can_view_chirp(chirp_id UUID, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE SQL STABLE AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM chirps
+        INNER JOIN users ON users.id = chirps.user_id
+        WHERE chirps.id = can_view_chirp.chirp_id
+            AND chirps.status = 'published'
+            AND chirps.moderation_status = 'approved'
+            AND (users.suspended_at IS NULL OR users.suspended_until <= NOW())
+            AND (
+                users.id = can_view_chirp.viewer_id
+                OR (
+                    users.shadowbanned_at IS NULL
+                    AND NOT EXISTS (
+                        SELECT 1 FROM blocks
+                        WHERE (blocks.blocker_id = can_view_chirp.viewer_id AND blocks.blocked_id = users.id)
+                            OR (blocks.blocker_id = users.id AND blocks.blocked_id = can_view_chirp.viewer_id)
+                    )
+                    AND (
+                        NOT users.is_private
+                        OR EXISTS (
+                            SELECT 1 FROM follows
+                            WHERE follows.follower_id = can_view_chirp.viewer_id AND follows.followee_id = users.id
+                        )
+                    )
+                    AND CASE chirps.visibility
+                        WHEN 'followers' THEN EXISTS (
+                            SELECT 1 FROM follows
+                            WHERE follows.follower_id = can_view_chirp.viewer_id AND follows.followee_id = users.id
+                        )
+                        WHEN 'mentioned' THEN EXISTS (
+                            SELECT 1 FROM chirp_entities
+                            WHERE chirp_entities.chirp_id = chirps.id
+                                AND chirp_entities.kind = 'mention'
+                                AND chirp_entities.user_id = can_view_chirp.viewer_id
+                        )
+                        ELSE TRUE
+                    END
+                )
+            )
+    )
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION can_view_chirp(chirp_id UUID, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE SQL STABLE AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM chirps
+        INNER JOIN users ON users.id = chirps.user_id
+        WHERE chirps.id = can_view_chirp.chirp_id
+            AND chirps.moderation_status = 'approved'
+            AND (users.suspended_at IS NULL OR users.suspended_until <= NOW())
+            AND (
+                users.id = can_view_chirp.viewer_id
+                OR (
+                    users.shadowbanned_at IS NULL
+                    AND NOT EXISTS (
+                        SELECT 1 FROM blocks
+                        WHERE (blocks.blocker_id = can_view_chirp.viewer_id AND blocks.blocked_id = users.id)
+                            OR (blocks.blocker_id = users.id AND blocks.blocked_id = can_view_chirp.viewer_id)
+                    )
+                    AND (
+                        NOT users.is_private
+                        OR EXISTS (
+                            SELECT 1 FROM follows
+                            WHERE follows.follower_id = can_view_chirp.viewer_id AND follows.followee_id = users.id
+                        )
+                    )
+                    AND CASE chirps.visibility
+                        WHEN 'followers' THEN EXISTS (
+                            SELECT 1 FROM follows
+                            WHERE follows.follower_id = can_view_chirp.viewer_id AND follows.followee_id = users.id
+                        )
+                        WHEN 'mentioned' THEN EXISTS (
+                            SELECT 1 FROM chirp_entities
+                            WHERE chirp_entities.chirp_id = chirps.id
+                                AND chirp_entities.kind = 'mention'
+                                AND chirp_entities.user_id = can_view_chirp.viewer_id
+                        )
+                        ELSE TRUE
+                    END
+                )
+            )
+    )
+$$;
+-- +goose StatementEnd
+
+DROP INDEX chirps_scheduled_idx;
+DROP INDEX chirps_drafts_idx;
+ALTER TABLE chirps DROP COLUMN publish_at;
+ALTER TABLE chirps DROP COLUMN status;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE pinned_chirps (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- Deleting a chirp unpins it.
+    chirp_id UUID NOT NULL UNIQUE REFERENCES chirps(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX pinned_chirps_user_id_idx ON pinned_chirps (user_id, position);
+
+-- +goose Down
+DROP TABLE pinned_chirps;
@@ -0,0 +1,107 @@
+-- +goose Up
+-- Chirps deleted by their author can be restored until they're purged.
+-- Chirps removed by a moderator are kept for review and appeals.
+ALTER TABLE chirps ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE chirps ADD COLUMN removed_at TIMESTAMP;
+ALTER TABLE chirps ADD COLUMN removed_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX chirps_deleted_at_idx ON chirps (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Deleted and removed chirps are hidden from everyone, their author included.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION can_view_chirp(chirp_id UUID, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE SQL STABLE AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM chirps
+        INNER JOIN users ON users.id = chirps.user_id
+        WHERE chirps.id = can_view_chirp.chirp_id
+            AND chirps.status = 'published'
+            AND chirps.deleted_at IS NULL
+            AND chirps.removed_at IS NULL
+            AND chirps.moderation_status = 'approved'
+            AND (users.suspended_at IS NULL OR users.suspended_until <= NOW())
+            AND (
+                users.id = can_view_chirp.viewer_id
+                OR (
+                    users.shadowbanned_at IS NULL
+                    AND NOT EXISTS (
+                        SELECT 1 FROM blocks
+                        WHERE (blocks.blocker_id = can_view_chirp.viewer_id AND blocks.blocked_id = users.id)
+                            OR (blocks.blocker_id = users.id AND blocks.blocked_id = can_view_chirp.viewer_id)
+                    )
+                    AND (
+                        NOT users.is_private
+                        OR EXISTS (
+                            SELECT 1 FROM follows
+                            WHERE follows.follower_id = can_view_chirp.viewer_id AND follows.followee_id = users.id
+                        )
+                    )
+                    AND CASE chirps.visibility
+                        WHEN 'followers' THEN EXISTS (
+                            SELECT 1 FROM follows
+                            WHERE follows.follower_id = can_view_chirp.viewer_id AND follows.followee_id = users.id
+                        )
+                        WHEN 'mentioned' THEN EXISTS (
+                            SELECT 1 FROM chirp_entities
+                            WHERE chirp_entities.chirp_id = chirps.id
+                                AND chirp_entities.kind = 'mention'
+                                AND chirp_entities.user_id = can_view_chirp.viewer_id
+                        )
+                        ELSE TRUE
+                    END
+                )
+            )
+    )
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION can_view_chirp(chirp_id UUID, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE SQL STABLE AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM chirps
+        INNER JOIN users ON users.id = chirps.user_id
+        WHERE chirps.id = can_view_chirp.chirp_id
+            AND chirps.status = 'published'
+            AND chirps.moderation_status = 'approved'
+            AND (users.suspended_at IS NULL OR users.suspended_until <= NOW())
+            AND (
+                users.id = can_view_chirp.viewer_id
+                OR (
+                    users.shadowbanned_at IS NULL
+                    AND NOT EXISTS (
+                        SELECT 1 FROM blocks
+                        WHERE (blocks.blocker_id = can_view_chirp.viewer_id AND blocks.blocked_id = users.id)
+                            OR (blocks.blocker_id = users.id AND blocks.blocked_id = can_view_chirp.viewer_id)
+                    )
+                    AND (
+                        NOT users.is_private
+                        OR EXISTS (
+                            SELECT 1 FROM follows
+                            WHERE follows.follower_id = can_view_chirp.viewer_id AND follows.followee_id = users.id
+                        )
+                    )
+                    AND CASE chirps.visibility
+                        WHEN 'followers' THEN EXISTS (
+                            SELECT 1 FROM follows
+                            WHERE follows.follower_id = can_view_chirp.viewer_id AND follows.followee_id = users.id
+                        )
+                        WHEN 'mentioned' THEN EXISTS (
+                            SELECT 1 FROM chirp_entities
+                            WHERE chirp_entities.chirp_id = chirps.id
+                                AND chirp_entities.kind = 'mention'
+                                AND chirp_entities.user_id = can_view_chirp.viewer_id
+                        )
+                        ELSE TRUE
+                    END
+                )
+            )
+    )
+$$;
+-- +goose StatementEnd
+
+DROP INDEX chirps_deleted_at_idx;
+ALTER TABLE chirps DROP COLUMN removed_by;
+ALTER TABLE chirps DROP COLUMN removed_at;
+ALTER TABLE chirps DROP COLUMN deleted_at;
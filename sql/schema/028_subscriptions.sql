@@ -0,0 +1,26 @@
+-- +goose Up
+-- Each row is one billing period of a Chirpy Red subscription. Renewing
+-- starts a new row, so the rows of a user are their billing history.
+CREATE TABLE subscriptions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    plan TEXT NOT NULL,
+    status TEXT NOT NULL,
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL
+);
+
+CREATE INDEX subscriptions_user_id_idx ON subscriptions (user_id, created_at DESC, id DESC);
+CREATE INDEX subscriptions_current_idx ON subscriptions (period_end) WHERE status IN ('active', 'canceled');
+
+-- Users upgraded before subscriptions were tracked get a first period, which
+-- Polka's next renewal extends.
+INSERT INTO subscriptions (id, created_at, updated_at, user_id, plan, status, period_start, period_end)
+SELECT gen_random_uuid(), NOW(), NOW(), id, 'chirpy_red', 'active', NOW(), NOW() + INTERVAL '30 days'
+FROM users
+WHERE is_chirpy_red;
+
+-- +goose Down
+DROP TABLE subscriptions;
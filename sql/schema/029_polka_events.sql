@@ -0,0 +1,20 @@
+-- +goose Up
+-- Every webhook Polka delivers, stored as received. event_id is Polka's and
+-- repeats when a delivery is retried.
+CREATE TABLE polka_events (
+    id UUID PRIMARY KEY,
+    event_id TEXT NOT NULL UNIQUE,
+    received_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    event TEXT NOT NULL,
+    payload BYTEA NOT NULL,
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    processed_at TIMESTAMP
+);
+
+CREATE INDEX polka_events_failed_idx ON polka_events (received_at DESC, id DESC) WHERE status = 'failed';
+
+-- +goose Down
+DROP TABLE polka_events;
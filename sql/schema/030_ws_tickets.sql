@@ -0,0 +1,12 @@
+-- +goose Up
+-- Single-use tickets that authenticate a WebSocket upgrade, so the access
+-- token never appears in a URL.
+CREATE TABLE ws_tickets (
+    ticket TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE ws_tickets;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Chirps waiting to be copied into their followers' timelines. A job is
+-- created in the transaction that publishes its chirp, so none are lost when
+-- an instance stops before the fan-out runs.
+CREATE TABLE fanout_jobs (
+    chirp_id UUID PRIMARY KEY REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    run_at TIMESTAMP NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX fanout_jobs_run_at_idx ON fanout_jobs (run_at);
+
+-- +goose Down
+DROP TABLE fanout_jobs;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Home timelines include the user's own chirps. Seed each user's latest ones.
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT users.id, c.id, users.id, c.created_at FROM users
+CROSS JOIN LATERAL (
+    SELECT chirps.id, chirps.created_at FROM chirps
+    WHERE chirps.user_id = users.id
+        AND chirps.status = 'published'
+        AND chirps.deleted_at IS NULL
+        AND chirps.removed_at IS NULL
+    ORDER BY chirps.created_at DESC
+    LIMIT 100
+) AS c
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM timeline_entries
+WHERE user_id = author_id;
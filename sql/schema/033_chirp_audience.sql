@@ -0,0 +1,112 @@
+-- +goose Up
+-- chirp_audience_includes holds the rules about who a chirp is for: blocks,
+-- private accounts and the chirp's visibility level. can_view_chirp adds the
+-- chirp's own state on top. Deletions are streamed to the audience alone,
+-- since by then the chirp itself is gone.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_audience_includes(chirp_id UUID, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE SQL STABLE AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM chirps
+        INNER JOIN users ON users.id = chirps.user_id
+        WHERE chirps.id = chirp_audience_includes.chirp_id
+            AND (
+                users.id = chirp_audience_includes.viewer_id
+                OR (
+                    users.shadowbanned_at IS NULL
+                    AND NOT EXISTS (
+                        SELECT 1 FROM blocks
+                        WHERE (blocks.blocker_id = chirp_audience_includes.viewer_id AND blocks.blocked_id = users.id)
+                            OR (blocks.blocker_id = users.id AND blocks.blocked_id = chirp_audience_includes.viewer_id)
+                    )
+                    AND (
+                        NOT users.is_private
+                        OR EXISTS (
+                            SELECT 1 FROM follows
+                            WHERE follows.follower_id = chirp_audience_includes.viewer_id AND follows.followee_id = users.id
+                        )
+                    )
+                    AND CASE chirps.visibility
+                        WHEN 'followers' THEN EXISTS (
+                            SELECT 1 FROM follows
+                            WHERE follows.follower_id = chirp_audience_includes.viewer_id AND follows.followee_id = users.id
+                        )
+                        WHEN 'mentioned' THEN EXISTS (
+                            SELECT 1 FROM chirp_entities
+                            WHERE chirp_entities.chirp_id = chirps.id
+                                AND chirp_entities.kind = 'mention'
+                                AND chirp_entities.user_id = chirp_audience_includes.viewer_id
+                        )
+                        ELSE TRUE
+                    END
+                )
+            )
+    )
+$$;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION can_view_chirp(chirp_id UUID, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE SQL STABLE AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM chirps
+        INNER JOIN users ON users.id = chirps.user_id
+        WHERE chirps.id = can_view_chirp.chirp_id
+            AND chirps.status = 'published'
+            AND chirps.deleted_at IS NULL
+            AND chirps.removed_at IS NULL
+            AND chirps.moderation_status = 'approved'
+            AND (users.suspended_at IS NULL OR users.suspended_until <= NOW())
+    ) AND chirp_audience_includes(can_view_chirp.chirp_id, can_view_chirp.viewer_id)
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION can_view_chirp(chirp_id UUID, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE SQL STABLE AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM chirps
+        INNER JOIN users ON users.id = chirps.user_id
+        WHERE chirps.id = can_view_chirp.chirp_id
+            AND chirps.status = 'published'
+            AND chirps.deleted_at IS NULL
+            AND chirps.removed_at IS NULL
+            AND chirps.moderation_status = 'approved'
+            AND (users.suspended_at IS NULL OR users.suspended_until <= NOW())
+            AND (
+                users.id = can_view_chirp.viewer_id
+                OR (
+                    users.shadowbanned_at IS NULL
+                    AND NOT EXISTS (
+                        SELECT 1 FROM blocks
+                        WHERE (blocks.blocker_id = can_view_chirp.viewer_id AND blocks.blocked_id = users.id)
+                            OR (blocks.blocker_id = users.id AND blocks.blocked_id = can_view_chirp.viewer_id)
+                    )
+                    AND (
+                        NOT users.is_private
+                        OR EXISTS (
+                            SELECT 1 FROM follows
+                            WHERE follows.follower_id = can_view_chirp.viewer_id AND follows.followee_id = users.id
+                        )
+                    )
+                    AND CASE chirps.visibility
+                        WHEN 'followers' THEN EXISTS (
+                            SELECT 1 FROM follows
+                            WHERE follows.follower_id = can_view_chirp.viewer_id AND follows.followee_id = users.id
+                        )
+                        WHEN 'mentioned' THEN EXISTS (
+                            SELECT 1 FROM chirp_entities
+                            WHERE chirp_entities.chirp_id = chirps.id
+                                AND chirp_entities.kind = 'mention'
+                                AND chirp_entities.user_id = can_view_chirp.viewer_id
+                        )
+                        ELSE TRUE
+                    END
+                )
+            )
+    )
+$$;
+-- +goose StatementEnd
+
+DROP FUNCTION chirp_audience_includes;
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/entities"
+	"github.com/matt-horst/chirpy/internal/stream"
+)
+
+const (
+	streamHeartbeatInterval = 15 * time.Second
+	maxStreamsPerUser       = 5
+	// maxStreamReplay caps how many missed events a reconnecting client is
+	// sent. Clients that missed more should reload with GET /api/chirps.
+	maxStreamReplay = 500
+	// chirpEventRetention is how long events stay available for resuming.
+	chirpEventRetention = 24 * time.Hour
+)
+
+// streamCounter counts open streams per user, or per IP address for anonymous
+// clients.
+type streamCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newStreamCounter() *streamCounter {
+	return &streamCounter{counts: map[string]int{}}
+}
+
+func (c *streamCounter) acquire(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.counts[key] >= maxStreamsPerUser {
+		return false
+	}
+	c.counts[key]++
+	return true
+}
+
+func (c *streamCounter) release(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[key]--
+	if c.counts[key] <= 0 {
+		delete(c.counts, key)
+	}
+}
+
+func newChirpEvent(event database.ChirpEvent) stream.Event {
+	return stream.Event{
+		ID:       event.ID,
+		Kind:     stream.Kind(event.Kind),
+		ChirpID:  event.ChirpID,
+		AuthorID: event.AuthorID,
+		Hashtags: event.Hashtags,
+		Unlisted: event.Unlisted,
+	}
+}
+
+// recordChirpEvent adds an event to the log clients resume from. Publish it
+// with publishChirpEvent once the surrounding transaction has committed.
+func recordChirpEvent(ctx context.Context, q *database.Queries, kind stream.Kind, chirp database.Chirp) (stream.Event, error) {
+	params := database.CreateChirpEventParams{
+		Kind:     string(kind),
+		ChirpID:  chirp.ID,
+		AuthorID: chirp.UserID.UUID,
+		Hashtags: entities.Values(entities.Parse(chirp.Body), entities.Hashtag),
+		Unlisted: chirp.Visibility == visibilityUnlisted,
+	}
+	event, err := q.CreateChirpEvent(ctx, params)
+	if err != nil {
+		return stream.Event{}, err
+	}
+
+	return newChirpEvent(event), nil
+}
+
+func (cfg *apiConfig) publishChirpEvent(event stream.Event) {
+	err := cfg.events.Publish(context.Background(), event)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+	}
+}
+
+func cleanupChirpEvents(q *database.Queries, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := q.DeleteChirpEventsBefore(context.Background(), time.Now().UTC().Add(-chirpEventRetention))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+}
+
+func (cfg *apiConfig) authorFilter(ctx context.Context, ref string) (stream.Filter, error) {
+	author, _, err := cfg.resolveUser(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(e stream.Event) bool {
+		return e.AuthorID == author.ID
+	}, nil
+}
+
+// listedFilter drops unlisted chirps from the streams that stand in for
+// public lists and search.
+func listedFilter(e stream.Event) bool {
+	return !e.Unlisted
+}
+
+func hashtagFilter(tag string) stream.Filter {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	return func(e stream.Event) bool {
+		return slices.Contains(e.Hashtags, tag)
+	}
+}
+
+// homeFilter passes chirps by the user and the accounts they follow. The set
+// of followed accounts is fixed when the filter is built.
+func (cfg *apiConfig) homeFilter(ctx context.Context, userID uuid.UUID) (stream.Filter, error) {
+	following, err := cfg.dbQueries.GetFollowingIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	authors := map[uuid.UUID]bool{userID: true}
+	for _, id := range following {
+		authors[id] = true
+	}
+
+	return func(e stream.Event) bool {
+		return authors[e.AuthorID]
+	}, nil
+}
+
+// mutedFilter drops chirps by accounts the user has muted.
+func (cfg *apiConfig) mutedFilter(ctx context.Context, userID uuid.UUID) (stream.Filter, error) {
+	muted, err := cfg.dbQueries.GetMutedUserIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(e stream.Event) bool {
+		return !slices.Contains(muted, e.AuthorID)
+	}, nil
+}
+
+func allOf(filters ...stream.Filter) stream.Filter {
+	return func(e stream.Event) bool {
+		for _, filter := range filters {
+			if !filter(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// streamFilter builds the filter for the author, hashtag and home query
+// parameters of a stream. All of them are optional and combine with AND.
+func (cfg *apiConfig) streamFilter(r *http.Request, viewerID uuid.NullUUID) (stream.Filter, int, error) {
+	query := r.URL.Query()
+	filters := []stream.Filter{stream.Event.IsChirpEvent}
+
+	// Without an author or the home timeline, the stream is a public list.
+	if query.Get("hashtag") != "" || (query.Get("author") == "" && query.Get("home") != "true") {
+		filters = append(filters, listedFilter)
+	}
+
+	if ref := query.Get("author"); ref != "" {
+		filter, err := cfg.authorFilter(r.Context(), ref)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, http.StatusNotFound, errors.New("couldn't find author")
+		} else if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		filters = append(filters, filter)
+	}
+
+	if tag := query.Get("hashtag"); tag != "" {
+		filters = append(filters, hashtagFilter(tag))
+	}
+
+	if query.Get("home") == "true" {
+		if !viewerID.Valid {
+			return nil, http.StatusUnauthorized, errors.New("home stream requires an access token")
+		}
+
+		filter, err := cfg.homeFilter(r.Context(), viewerID.UUID)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		filters = append(filters, filter)
+	}
+
+	if viewerID.Valid {
+		filter, err := cfg.mutedFilter(r.Context(), viewerID.UUID)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		filters = append(filters, filter)
+	}
+
+	return allOf(filters...), http.StatusOK, nil
+}
+
+// streamHandler sends new and deleted chirps as Server-Sent Events. Clients
+// that reconnect with a Last-Event-ID header are first sent the events they
+// missed.
+func (cfg *apiConfig) streamHandler(w http.ResponseWriter, r *http.Request) {
+	viewerID := cfg.viewerID(r)
+
+	filter, code, err := cfg.streamFilter(r, viewerID)
+	if err != nil {
+		if code == http.StatusInternalServerError {
+			fmt.Printf("Error: %v\n", err)
+			respondWithError(w, code, "failed to open stream")
+			return
+		}
+		respondWithError(w, code, err.Error())
+		return
+	}
+
+	var lastEventID int64
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		lastEventID, err = strconv.ParseInt(header, 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid Last-Event-ID")
+			return
+		}
+	}
+
+	key := "ip:" + r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		key = "ip:" + host
+	}
+	if viewerID.Valid {
+		key = "user:" + viewerID.UUID.String()
+	}
+
+	if !cfg.streams.acquire(key) {
+		respondWithError(w, http.StatusTooManyRequests, "too many open streams")
+		return
+	}
+	defer cfg.streams.release(key)
+
+	// Subscribe before replaying so nothing published in between is lost.
+	sub := cfg.broker.Subscribe(filter)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	err = rc.Flush()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	// Events can commit out of ID order, so the live stream only skips the
+	// ones already sent during replay.
+	replayed := map[int64]bool{}
+	if lastEventID > 0 {
+		params := database.GetChirpEventsAfterParams{AfterID: lastEventID, MaxEvents: maxStreamReplay}
+		missed, err := cfg.dbQueries.GetChirpEventsAfter(r.Context(), params)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		for _, row := range missed {
+			event := newChirpEvent(row)
+			if filter(event) {
+				err = cfg.writeStreamEvent(r.Context(), w, event, viewerID)
+				if err != nil {
+					return
+				}
+			}
+			replayed[event.ID] = true
+		}
+
+		err = rc.Flush()
+		if err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Dropped for falling behind. The client reconnects and resumes.
+				return
+			}
+
+			if replayed[event.ID] {
+				delete(replayed, event.ID)
+				continue
+			}
+
+			err = cfg.writeStreamEvent(r.Context(), w, event, viewerID)
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// writeStreamEvent writes one event. New chirps are sent in full, and only
+// if the viewer may see them; deleted chirps are sent as just their ID, and
+// only to their audience.
+func (cfg *apiConfig) writeStreamEvent(ctx context.Context, w http.ResponseWriter, event stream.Event, viewerID uuid.NullUUID) error {
+	var data any
+
+	switch event.Kind {
+	case stream.ChirpCreated:
+		chirp, err := cfg.dbQueries.GetSingleChirp(ctx, event.ChirpID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		visible, err := cfg.canViewChirp(ctx, chirp, viewerID)
+		if err != nil || !visible {
+			return err
+		}
+
+		data, err = cfg.chirpResponse(ctx, chirp, viewerID)
+		if err != nil {
+			return err
+		}
+	case stream.ChirpDeleted:
+		visible, err := cfg.canSeeChirpDeletion(ctx, event.ChirpID, viewerID)
+		if err != nil || !visible {
+			return err
+		}
+
+		data = struct {
+			ID uuid.UUID `json:"id"`
+		}{ID: event.ChirpID}
+	default:
+		return nil
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Kind, payload)
+	return err
+}
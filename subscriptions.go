@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/polka"
+	"github.com/matt-horst/chirpy/internal/subscriptions"
+)
+
+const subscriptionExpiryInterval = 10 * time.Minute
+
+var errUnknownPolkaUser = errors.New("couldn't find user")
+
+// Subscription is one billing period of Chirpy Red.
+type Subscription struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Plan        string    `json:"plan"`
+	Status      string    `json:"status"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Current     bool      `json:"current"`
+}
+
+// applyPolkaEvent updates a user's subscription from a Polka event and then
+// recomputes is_chirpy_red from it. The caller owns the transaction q runs in.
+func applyPolkaEvent(ctx context.Context, q *database.Queries, event polka.Event) error {
+	userID := event.Data.UserID
+	_, err := q.GetUserByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errUnknownPolkaUser
+	} else if err != nil {
+		return err
+	}
+
+	current, err := q.GetCurrentSubscription(ctx, userID)
+	hasCurrent := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	plan := event.Data.Plan
+	if plan == "" {
+		plan = subscriptions.DefaultPlan
+	}
+
+	switch event.Event {
+	case polka.UserUpgraded:
+		// Upgrading while subscribed, say after canceling, keeps the period.
+		// A period that has run out but hasn't been expired yet is expired
+		// now and replaced.
+		now := time.Now()
+		if hasCurrent && current.PeriodEnd.After(now) {
+			err = q.SetSubscriptionStatus(ctx, database.SetSubscriptionStatusParams{ID: current.ID, Status: subscriptions.StatusActive})
+		} else {
+			if hasCurrent {
+				err = q.SetSubscriptionStatus(ctx, database.SetSubscriptionStatusParams{ID: current.ID, Status: subscriptions.StatusExpired})
+			}
+			if err == nil {
+				err = createSubscription(ctx, q, userID, plan, event, now)
+			}
+		}
+	case polka.SubscriptionRenewed:
+		from := time.Now()
+		if hasCurrent {
+			from = current.PeriodEnd
+			err = q.SetSubscriptionStatus(ctx, database.SetSubscriptionStatusParams{ID: current.ID, Status: subscriptions.StatusRenewed})
+		}
+		if err == nil {
+			err = createSubscription(ctx, q, userID, plan, event, from)
+		}
+	case polka.SubscriptionCanceled:
+		if hasCurrent {
+			err = q.SetSubscriptionStatus(ctx, database.SetSubscriptionStatusParams{ID: current.ID, Status: subscriptions.StatusCanceled})
+		}
+	case polka.UserDowngraded:
+		if hasCurrent {
+			err = q.EndSubscription(ctx, database.EndSubscriptionParams{ID: current.ID, Status: subscriptions.StatusDowngraded})
+		}
+	default:
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return q.SyncChirpyRed(ctx, userID)
+}
+
+func createSubscription(ctx context.Context, q *database.Queries, userID uuid.UUID, plan string, event polka.Event, from time.Time) error {
+	start, end, err := subscriptions.Period(event.Data.PeriodStart, event.Data.PeriodEnd, from)
+	if err != nil {
+		return err
+	}
+
+	params := database.CreateSubscriptionParams{
+		UserID:      userID,
+		Plan:        plan,
+		Status:      subscriptions.StatusActive,
+		PeriodStart: start,
+		PeriodEnd:   end,
+	}
+	_, err = q.CreateSubscription(ctx, params)
+	return err
+}
+
+// expireSubscriptions periodically ends the subscriptions whose period has
+// passed without a renewal, taking Chirpy Red away from their users.
+func (cfg *apiConfig) expireSubscriptions(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := cfg.expireLapsedSubscriptions(context.Background())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+}
+
+func (cfg *apiConfig) expireLapsedSubscriptions(ctx context.Context) error {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	userIDs, err := qtx.ExpireSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		err = qtx.SyncChirpyRed(ctx, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// getSubscriptionsHandler lists the user's billing history, newest first.
+func (cfg *apiConfig) getSubscriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	after, limit, err := parseCursorPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetSubscriptionsParams{
+		UserID:          userID,
+		BeforeCreatedAt: after.CreatedAt,
+		BeforeID:        after.ID,
+		PageSize:        limit,
+	}
+	rows, err := cfg.dbQueries.GetSubscriptions(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get subscriptions")
+		return
+	}
+
+	now := time.Now().UTC()
+	resp := Page[Subscription]{Items: []Subscription{}}
+	for _, row := range rows {
+		resp.Items = append(resp.Items, Subscription{
+			ID:          row.ID,
+			CreatedAt:   row.CreatedAt,
+			Plan:        row.Plan,
+			Status:      row.Status,
+			PeriodStart: row.PeriodStart,
+			PeriodEnd:   row.PeriodEnd,
+			Current:     subscriptions.IsCurrent(row.Status) && row.PeriodEnd.After(now),
+		})
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		resp.NextCursor = nextCursor(len(rows), limit, cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
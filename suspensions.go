@@ -0,0 +1,390 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+)
+
+// Values of appeals.status.
+const (
+	appealPending  = "pending"
+	appealAccepted = "accepted"
+	appealRejected = "rejected"
+)
+
+type Appeal struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	UserID      uuid.UUID `json:"user_id"`
+	SuspendedAt time.Time `json:"suspended_at"`
+	Message     string    `json:"message"`
+	Status      string    `json:"status"`
+}
+
+func newAppeal(appeal database.Appeal) Appeal {
+	return Appeal{
+		ID:          appeal.ID,
+		CreatedAt:   appeal.CreatedAt,
+		UpdatedAt:   appeal.UpdatedAt,
+		UserID:      appeal.UserID,
+		SuspendedAt: appeal.SuspendedAt,
+		Message:     appeal.Message,
+		Status:      appeal.Status,
+	}
+}
+
+// isSuspended reports whether the user has a suspension that hasn't ended yet.
+func isSuspended(user database.User) bool {
+	if !user.SuspendedAt.Valid {
+		return false
+	}
+
+	return !user.SuspendedUntil.Valid || time.Now().Before(user.SuspendedUntil.Time)
+}
+
+func recordUserModerationAction(ctx context.Context, q *database.Queries, moderatorID, userID uuid.UUID, action, note string) error {
+	params := database.CreateModerationActionParams{
+		ModeratorID:  moderatorID,
+		TargetUserID: uuid.NullUUID{UUID: userID, Valid: true},
+		Action:       action,
+		Note:         note,
+	}
+	_, err := q.CreateModerationAction(ctx, params)
+	return err
+}
+
+// suspensionEnd checks the optional end of a suspension. Without one the
+// suspension lasts until it's lifted.
+func suspensionEnd(until *time.Time) (sql.NullTime, error) {
+	if until == nil {
+		return sql.NullTime{}, nil
+	}
+
+	if until.Before(time.Now()) {
+		return sql.NullTime{}, errors.New("suspension must end in the future")
+	}
+
+	return sql.NullTime{Time: until.UTC(), Valid: true}, nil
+}
+
+// suspendUser suspends a user and records the moderator's action. chirpID is
+// the chirp that led to the suspension, if any.
+func suspendUser(ctx context.Context, q *database.Queries, moderatorID, userID uuid.UUID, chirpID uuid.NullUUID, until sql.NullTime, reason string) error {
+	params := database.SuspendUserParams{ID: userID, SuspendedUntil: until, SuspensionReason: reason}
+	_, err := q.SuspendUser(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	actionParams := database.CreateModerationActionParams{
+		ModeratorID:  moderatorID,
+		ChirpID:      chirpID,
+		TargetUserID: uuid.NullUUID{UUID: userID, Valid: true},
+		Action:       "suspend",
+		Note:         reason,
+	}
+	_, err = q.CreateModerationAction(ctx, actionParams)
+	return err
+}
+
+func (cfg *apiConfig) suspendUserHandler(w http.ResponseWriter, r *http.Request) {
+	moderator, ok := cfg.authenticateModerator(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	data := struct {
+		Until  *time.Time `json:"until"`
+		Reason string     `json:"reason"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	until, err := suspensionEnd(data.Until)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to suspend user")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	err = suspendUser(r.Context(), qtx, moderator.ID, userID, uuid.NullUUID{}, until, data.Reason)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "couldn't find user")
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to suspend user")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to suspend user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) liftSuspensionHandler(w http.ResponseWriter, r *http.Request) {
+	cfg.userModerationHandler(w, r, "unsuspend", func(q *database.Queries, userID uuid.UUID) error {
+		_, err := q.LiftUserSuspension(r.Context(), userID)
+		return err
+	})
+}
+
+func (cfg *apiConfig) shadowbanUserHandler(w http.ResponseWriter, r *http.Request) {
+	cfg.userModerationHandler(w, r, "shadowban", func(q *database.Queries, userID uuid.UUID) error {
+		_, err := q.ShadowbanUser(r.Context(), userID)
+		return err
+	})
+}
+
+func (cfg *apiConfig) liftShadowbanHandler(w http.ResponseWriter, r *http.Request) {
+	cfg.userModerationHandler(w, r, "unshadowban", func(q *database.Queries, userID uuid.UUID) error {
+		_, err := q.LiftUserShadowban(r.Context(), userID)
+		return err
+	})
+}
+
+// userModerationHandler handles the moderator actions against the user in the
+// path that take no request body.
+func (cfg *apiConfig) userModerationHandler(w http.ResponseWriter, r *http.Request, action string, apply func(*database.Queries, uuid.UUID) error) {
+	moderator, ok := cfg.authenticateModerator(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to update user")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	err = apply(qtx, userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "couldn't find user")
+		return
+	}
+
+	err = recordUserModerationAction(r.Context(), qtx, moderator.ID, userID, action, "")
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to update user")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to update user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// createAppealHandler takes the user's credentials rather than an access token
+// since suspended users can't log in.
+func (cfg *apiConfig) createAppealHandler(w http.ResponseWriter, r *http.Request) {
+	data := struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+		Message  string `json:"message"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUser(r.Context(), data.Email)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Incorrect email and password")
+		return
+	}
+
+	ok, err := auth.CheckPasswordHash(data.Password, user.HashedPassword)
+	if err != nil || !ok {
+		respondWithError(w, http.StatusUnauthorized, "Incorrect email and password")
+		return
+	}
+
+	if !isSuspended(user) {
+		respondWithError(w, http.StatusBadRequest, "account is not suspended")
+		return
+	}
+
+	if data.Message == "" {
+		respondWithError(w, http.StatusBadRequest, "appeal requires a message")
+		return
+	}
+
+	params := database.CreateAppealParams{
+		UserID:      user.ID,
+		SuspendedAt: user.SuspendedAt.Time,
+		Message:     data.Message,
+	}
+	appeal, err := cfg.dbQueries.CreateAppeal(r.Context(), params)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "suspension has already been appealed")
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to create appeal")
+		return
+	}
+
+	respondWithJson(w, http.StatusCreated, newAppeal(appeal))
+}
+
+func (cfg *apiConfig) getAppealsHandler(w http.ResponseWriter, r *http.Request) {
+	_, ok := cfg.authenticateModerator(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset := parseLimitOffset(r)
+	appeals, err := cfg.dbQueries.GetPendingAppeals(r.Context(), database.GetPendingAppealsParams{Limit: limit, Offset: offset})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get appeals")
+		return
+	}
+
+	resp := []Appeal{}
+	for _, appeal := range appeals {
+		resp = append(resp, newAppeal(appeal))
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) resolveAppealHandler(w http.ResponseWriter, r *http.Request) {
+	moderator, ok := cfg.authenticateModerator(w, r)
+	if !ok {
+		return
+	}
+
+	appealID, err := uuid.Parse(r.PathValue("appealID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid appeal id")
+		return
+	}
+
+	data := struct {
+		Decision string `json:"decision"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	var status string
+	switch data.Decision {
+	case "accept":
+		status = appealAccepted
+	case "reject":
+		status = appealRejected
+	default:
+		respondWithError(w, http.StatusBadRequest, "decision must be accept or reject")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to resolve appeal")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	appeal, err := qtx.GetAppealForUpdate(r.Context(), appealID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "no appeal found")
+		return
+	}
+
+	if appeal.Status != appealPending {
+		respondWithError(w, http.StatusConflict, "appeal has already been resolved")
+		return
+	}
+
+	params := database.ResolveAppealParams{
+		ID:         appealID,
+		Status:     status,
+		ReviewerID: uuid.NullUUID{UUID: moderator.ID, Valid: true},
+	}
+	appeal, err = qtx.ResolveAppeal(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to resolve appeal")
+		return
+	}
+
+	if status == appealAccepted {
+		_, err = qtx.LiftUserSuspension(r.Context(), appeal.UserID)
+		if err == nil {
+			err = recordUserModerationAction(r.Context(), qtx, moderator.ID, appeal.UserID, "unsuspend", "appeal accepted")
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			respondWithError(w, http.StatusInternalServerError, "failed to resolve appeal")
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to resolve appeal")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, newAppeal(appeal))
+}
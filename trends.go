@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/trends"
+)
+
+// refreshTrendsInterval is how stale GET /api/trends may get.
+const refreshTrendsInterval = 5 * time.Minute
+
+// A language tag such as "en" or "pt-br".
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})?$`)
+
+// normalizeLocale lowercases a locale and reports whether it is valid. An
+// empty locale is valid and means none was set.
+func normalizeLocale(locale string) (string, bool) {
+	locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	return locale, locale == "" || localePattern.MatchString(locale)
+}
+
+type TrendingHashtag struct {
+	Tag           string  `json:"tag"`
+	Score         float64 `json:"score"`
+	RecentCount   int64   `json:"recent_count"`
+	BaselineCount int64   `json:"baseline_count"`
+	AuthorCount   int64   `json:"author_count"`
+}
+
+type DeniedTrend struct {
+	Tag       string     `json:"tag"`
+	CreatedAt time.Time  `json:"created_at"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+}
+
+func (cfg *apiConfig) getTrendsHandler(w http.ResponseWriter, r *http.Request) {
+	locale, ok := normalizeLocale(r.URL.Query().Get("locale"))
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "invalid locale")
+		return
+	}
+
+	ranked, computedAt := cfg.trends.Trends(locale)
+
+	resp := struct {
+		Locale     string            `json:"locale"`
+		ComputedAt time.Time         `json:"computed_at"`
+		Trends     []TrendingHashtag `json:"trends"`
+	}{
+		Locale:     locale,
+		ComputedAt: computedAt,
+		Trends:     []TrendingHashtag{},
+	}
+	for _, trend := range ranked {
+		resp.Trends = append(resp.Trends, TrendingHashtag{
+			Tag:           trend.Tag,
+			Score:         trend.Score,
+			RecentCount:   trend.Recent,
+			BaselineCount: trend.Baseline,
+			AuthorCount:   trend.Authors,
+		})
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) getTrendDenylistHandler(w http.ResponseWriter, r *http.Request) {
+	_, ok := cfg.authenticateModerator(w, r)
+	if !ok {
+		return
+	}
+
+	denied, err := cfg.dbQueries.GetTrendDenylist(r.Context())
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to get denylist")
+		return
+	}
+
+	resp := []DeniedTrend{}
+	for _, entry := range denied {
+		d := DeniedTrend{Tag: entry.Tag, CreatedAt: entry.CreatedAt}
+		if entry.CreatedBy.Valid {
+			d.CreatedBy = &entry.CreatedBy.UUID
+		}
+		resp = append(resp, d)
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) denyTrendHandler(w http.ResponseWriter, r *http.Request) {
+	moderator, ok := cfg.authenticateModerator(w, r)
+	if !ok {
+		return
+	}
+
+	data := struct {
+		Tag string `json:"tag"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	tag := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(data.Tag), "#"))
+	if tag == "" {
+		respondWithError(w, http.StatusBadRequest, "invalid hashtag")
+		return
+	}
+
+	params := database.AddTrendDenylistParams{
+		Tag:       tag,
+		CreatedBy: uuid.NullUUID{UUID: moderator.ID, Valid: true},
+	}
+	err = cfg.dbQueries.AddTrendDenylist(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to deny hashtag")
+		return
+	}
+
+	cfg.trends.Remove(tag)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) allowTrendHandler(w http.ResponseWriter, r *http.Request) {
+	_, ok := cfg.authenticateModerator(w, r)
+	if !ok {
+		return
+	}
+
+	tag := strings.ToLower(strings.TrimPrefix(r.PathValue("tag"), "#"))
+	removed, err := cfg.dbQueries.RemoveTrendDenylist(r.Context(), tag)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to allow hashtag")
+		return
+	}
+
+	if removed == 0 {
+		respondWithError(w, http.StatusNotFound, "hashtag isn't denied")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newTrendTracker(db *database.Queries) *trends.Tracker {
+	tracker := trends.NewTracker(db, trends.DefaultConfig())
+	go tracker.Run(refreshTrendsInterval)
+	return tracker
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+)
+
+// Values of chirps.visibility. Unlisted chirps are left out of public lists
+// and search but are otherwise public.
+const (
+	visibilityPublic    = "public"
+	visibilityUnlisted  = "unlisted"
+	visibilityFollowers = "followers"
+	visibilityMentioned = "mentioned"
+)
+
+var chirpVisibilities = []string{visibilityPublic, visibilityUnlisted, visibilityFollowers, visibilityMentioned}
+
+// canViewChirp decides whether the viewer may see a single chirp. The rules
+// live in the can_view_chirp SQL function, which the list queries call as
+// well. A missing viewer is an anonymous request.
+func (cfg *apiConfig) canViewChirp(ctx context.Context, chirp database.Chirp, viewerID uuid.NullUUID) (bool, error) {
+	return cfg.dbQueries.CanViewChirp(ctx, database.CanViewChirpParams{ChirpID: chirp.ID, ViewerID: viewerID})
+}
+
+// canSeeChirpDeletion decides whether a deleted or hidden chirp was meant for
+// the viewer, so that its deletion is only streamed to its audience.
+func (cfg *apiConfig) canSeeChirpDeletion(ctx context.Context, chirpID uuid.UUID, viewerID uuid.NullUUID) (bool, error) {
+	return cfg.dbQueries.InChirpAudience(ctx, database.InChirpAudienceParams{ChirpID: chirpID, ViewerID: viewerID})
+}
+
+func (cfg *apiConfig) updateChirpVisibilityHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id")
+		return
+	}
+
+	data := struct {
+		Visibility string `json:"visibility"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request data")
+		return
+	}
+
+	if !slices.Contains(chirpVisibilities, data.Visibility) {
+		respondWithError(w, http.StatusBadRequest, "invalid visibility")
+		return
+	}
+
+	chirp, err := cfg.dbQueries.GetSingleChirp(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "no chirp found")
+		return
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to update visibility")
+		return
+	}
+
+	if chirp.UserID.UUID != userID {
+		respondWithError(w, http.StatusForbidden, "invalid author")
+		return
+	}
+
+	params := database.SetChirpVisibilityParams{ID: chirp.ID, Visibility: data.Visibility}
+	chirp, err = cfg.dbQueries.SetChirpVisibility(r.Context(), params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to update visibility")
+		return
+	}
+
+	resp, err := cfg.chirpResponse(r.Context(), chirp, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to update visibility")
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, resp)
+}
@@ -0,0 +1,623 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/matt-horst/chirpy/internal/auth"
+	"github.com/matt-horst/chirpy/internal/database"
+	"github.com/matt-horst/chirpy/internal/stream"
+)
+
+// The WebSocket protocol is versioned separately from the REST API. Every
+// frame in either direction carries the version in its "v" field.
+const wsProtocolVersion = 1
+
+const (
+	wsPingInterval = 30 * time.Second
+	// wsPongWait is how long a client may stay silent, pongs included,
+	// before it is considered gone.
+	wsPongWait  = 2 * wsPingInterval
+	wsWriteWait = 10 * time.Second
+	// wsMaxMessageSize bounds the frames a client may send.
+	wsMaxMessageSize = 64 << 10
+	// wsSendBuffer is how many frames may queue for a client before it is
+	// dropped as too slow.
+	wsSendBuffer    = 64
+	maxWSChannels   = 50
+	wsTypingTimeout = 3 * time.Second
+)
+
+// wsRequest is a frame sent by the client.
+type wsRequest struct {
+	V       int    `json:"v"`
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+	Channel string `json:"channel"`
+	// ConversationID is the conversation a typing frame is for.
+	ConversationID *uuid.UUID `json:"conversation_id"`
+}
+
+// wsMessage is a frame sent by the server. ID echoes the request it answers.
+type wsMessage struct {
+	V       int    `json:"v"`
+	Type    string `json:"type"`
+	ID      string `json:"id,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	Event   string `json:"event,omitempty"`
+	Data    any    `json:"data,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// wsRegistry tracks open WebSocket clients so they can be closed cleanly on
+// shutdown.
+type wsRegistry struct {
+	mu      sync.Mutex
+	clients map[*wsClient]struct{}
+	closing bool
+	wg      sync.WaitGroup
+}
+
+func newWSRegistry() *wsRegistry {
+	return &wsRegistry{clients: map[*wsClient]struct{}{}}
+}
+
+func (reg *wsRegistry) add(c *wsClient) bool {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if reg.closing {
+		return false
+	}
+
+	reg.clients[c] = struct{}{}
+	reg.wg.Add(1)
+	return true
+}
+
+func (reg *wsRegistry) remove(c *wsClient) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if _, ok := reg.clients[c]; ok {
+		delete(reg.clients, c)
+		reg.wg.Done()
+	}
+}
+
+// shutdown tells every client the server is going away and waits for their
+// handlers to return. http.Server doesn't track hijacked connections, so it
+// is registered with RegisterOnShutdown.
+func (reg *wsRegistry) shutdown() {
+	reg.mu.Lock()
+	reg.closing = true
+	clients := []*wsClient{}
+	for c := range reg.clients {
+		clients = append(clients, c)
+	}
+	reg.mu.Unlock()
+
+	for _, c := range clients {
+		c.close(websocket.CloseGoingAway, "server shutting down")
+	}
+
+	reg.wg.Wait()
+}
+
+type wsClient struct {
+	cfg    *apiConfig
+	conn   *websocket.Conn
+	userID uuid.UUID
+	send   chan []byte
+	done   chan struct{}
+	once   sync.Once
+
+	mu       sync.Mutex
+	channels map[string]stream.Filter
+	muted    stream.Filter
+	// lastTyping is keyed by conversation.
+	lastTyping map[uuid.UUID]time.Time
+}
+
+func (c *wsClient) close(code int, reason string) {
+	c.once.Do(func() {
+		close(c.done)
+		closeWS(c.conn, code, reason)
+	})
+}
+
+// closeWS sends a close frame, best effort, and closes the connection.
+func closeWS(conn *websocket.Conn, code int, reason string) {
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteWait))
+	conn.Close()
+}
+
+// wants decides which channel, if any, an event is delivered on. It runs
+// inside the broker, so it must not block.
+func (c *wsClient) wants(e stream.Event) bool {
+	_, ok := c.channelFor(e)
+	return ok
+}
+
+func (c *wsClient) channelFor(e stream.Event) (string, bool) {
+	switch e.Kind {
+	case stream.MessageCreated, stream.Typing:
+		return "", e.UserID == c.userID
+	case stream.NotificationCreated:
+		if e.UserID != c.userID {
+			return "", false
+		}
+	default:
+		if !c.muted(e) {
+			return "", false
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name, filter := range c.channels {
+		if filter(e) {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// enqueue queues a frame without blocking. A client that can't keep up is
+// disconnected; it is expected to reconnect and catch up over REST.
+func (c *wsClient) enqueue(msg wsMessage) {
+	msg.V = wsProtocolVersion
+	frame, err := json.Marshal(msg)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	select {
+	case c.send <- frame:
+	case <-c.done:
+	default:
+		c.close(websocket.CloseTryAgainLater, "too slow")
+	}
+}
+
+func (c *wsClient) writeLoop() {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		var err error
+		select {
+		case <-c.done:
+			return
+		case frame := <-c.send:
+			err = c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err == nil {
+				err = c.conn.WriteMessage(websocket.TextMessage, frame)
+			}
+		case <-ping.C:
+			err = c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+		}
+		if err != nil {
+			c.close(websocket.CloseGoingAway, "")
+			return
+		}
+	}
+}
+
+// eventLoop turns broker events into frames.
+func (c *wsClient) eventLoop(sub *stream.Subscription) {
+	for {
+		select {
+		case <-c.done:
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				c.close(websocket.CloseTryAgainLater, "too slow")
+				return
+			}
+
+			channel, ok := c.channelFor(e)
+			if !ok {
+				continue
+			}
+
+			data, err := c.eventData(e)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				continue
+			}
+			if data == nil {
+				continue
+			}
+
+			c.enqueue(wsMessage{Type: "event", Channel: channel, Event: string(e.Kind), Data: data})
+		}
+	}
+}
+
+func (c *wsClient) eventData(e stream.Event) (any, error) {
+	ctx := context.Background()
+
+	switch e.Kind {
+	case stream.ChirpCreated:
+		chirp, err := c.cfg.dbQueries.GetSingleChirp(ctx, e.ChirpID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		viewerID := uuid.NullUUID{UUID: c.userID, Valid: true}
+		visible, err := c.cfg.canViewChirp(ctx, chirp, viewerID)
+		if err != nil || !visible {
+			return nil, err
+		}
+
+		return c.cfg.chirpResponse(ctx, chirp, viewerID)
+	case stream.ChirpDeleted:
+		viewerID := uuid.NullUUID{UUID: c.userID, Valid: true}
+		visible, err := c.cfg.canSeeChirpDeletion(ctx, e.ChirpID, viewerID)
+		if err != nil || !visible {
+			return nil, err
+		}
+
+		return struct {
+			ID uuid.UUID `json:"id"`
+		}{ID: e.ChirpID}, nil
+	case stream.NotificationCreated:
+		unread, err := c.cfg.dbQueries.CountUnreadNotifications(ctx, c.userID)
+		if err != nil {
+			return nil, err
+		}
+
+		return struct {
+			UnreadCount int64 `json:"unread_count"`
+		}{UnreadCount: unread}, nil
+	case stream.MessageCreated:
+		message, err := c.cfg.dbQueries.GetMessage(ctx, e.MessageID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		return messageResponse(message), nil
+	case stream.Typing:
+		return struct {
+			ConversationID uuid.UUID `json:"conversation_id"`
+			UserID         uuid.UUID `json:"user_id"`
+		}{ConversationID: e.ConversationID, UserID: e.AuthorID}, nil
+	}
+
+	return nil, nil
+}
+
+// channelFilter builds the filter behind a channel name:
+//
+//	public            every chirp
+//	home              chirps by the user and the accounts they follow
+//	author:<user>     chirps by one user, given by ID or handle
+//	hashtag:<tag>     chirps with a hashtag
+//	notifications     the user's new notifications
+func (c *wsClient) channelFilter(ctx context.Context, channel string) (stream.Filter, error) {
+	kind, arg, _ := strings.Cut(channel, ":")
+
+	switch kind {
+	case "public":
+		return allOf(stream.Event.IsChirpEvent, listedFilter), nil
+	case "home":
+		filter, err := c.cfg.homeFilter(ctx, c.userID)
+		if err != nil {
+			return nil, err
+		}
+		return allOf(stream.Event.IsChirpEvent, filter), nil
+	case "author":
+		filter, err := c.cfg.authorFilter(ctx, arg)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("couldn't find author")
+		} else if err != nil {
+			return nil, err
+		}
+		return allOf(stream.Event.IsChirpEvent, filter), nil
+	case "hashtag":
+		if arg == "" {
+			return nil, errors.New("invalid hashtag")
+		}
+		return allOf(stream.Event.IsChirpEvent, listedFilter, hashtagFilter(arg)), nil
+	case "notifications":
+		return func(e stream.Event) bool {
+			return e.Kind == stream.NotificationCreated
+		}, nil
+	}
+
+	return nil, errors.New("unknown channel")
+}
+
+func (c *wsClient) handle(req wsRequest) {
+	// Frames without a version predate versioning and are read as version 1.
+	if req.V == 0 {
+		req.V = 1
+	}
+
+	if req.V != wsProtocolVersion {
+		c.enqueue(wsMessage{Type: "error", ID: req.ID, Error: fmt.Sprintf("unsupported protocol version %d", req.V)})
+		return
+	}
+
+	switch req.Type {
+	case "subscribe":
+		c.mu.Lock()
+		_, subscribed := c.channels[req.Channel]
+		full := len(c.channels) >= maxWSChannels
+		c.mu.Unlock()
+
+		if subscribed {
+			c.enqueue(wsMessage{Type: "ack", ID: req.ID, Channel: req.Channel})
+			return
+		}
+
+		if full {
+			c.enqueue(wsMessage{Type: "error", ID: req.ID, Error: "too many channels"})
+			return
+		}
+
+		filter, err := c.channelFilter(context.Background(), req.Channel)
+		if err != nil {
+			c.enqueue(wsMessage{Type: "error", ID: req.ID, Error: err.Error()})
+			return
+		}
+
+		c.mu.Lock()
+		c.channels[req.Channel] = filter
+		c.mu.Unlock()
+
+		c.enqueue(wsMessage{Type: "ack", ID: req.ID, Channel: req.Channel})
+	case "unsubscribe":
+		c.mu.Lock()
+		delete(c.channels, req.Channel)
+		c.mu.Unlock()
+
+		c.enqueue(wsMessage{Type: "ack", ID: req.ID, Channel: req.Channel})
+	case "typing":
+		err := c.typing(req.ConversationID)
+		if err != nil {
+			c.enqueue(wsMessage{Type: "error", ID: req.ID, Error: err.Error()})
+			return
+		}
+	case "ping":
+		c.enqueue(wsMessage{Type: "pong", ID: req.ID})
+	default:
+		c.enqueue(wsMessage{Type: "error", ID: req.ID, Error: "unknown frame type"})
+	}
+}
+
+// typing tells the other members of a conversation that this user is typing,
+// at most once every wsTypingTimeout per conversation.
+func (c *wsClient) typing(conversationID *uuid.UUID) error {
+	if conversationID == nil {
+		return errors.New("invalid conversation")
+	}
+
+	params := database.GetConversationForMemberParams{ConversationID: *conversationID, UserID: c.userID}
+	_, err := c.cfg.dbQueries.GetConversationForMember(context.Background(), params)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("couldn't find conversation")
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return errors.New("failed to send typing indicator")
+	}
+
+	c.mu.Lock()
+	last := c.lastTyping[*conversationID]
+	throttled := time.Since(last) < wsTypingTimeout
+	if !throttled {
+		c.lastTyping[*conversationID] = time.Now()
+	}
+	c.mu.Unlock()
+
+	if throttled {
+		return nil
+	}
+
+	c.cfg.publishToConversation(context.Background(), stream.Event{Kind: stream.Typing, AuthorID: c.userID, ConversationID: *conversationID})
+	return nil
+}
+
+// createWSTicketHandler issues a ticket for opening a WebSocket session.
+// Browsers can't set headers on WebSocket requests, so they pass the ticket
+// in the URL instead of the access token. It expires after 30 seconds and
+// works once.
+func (cfg *apiConfig) createWSTicketHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+
+	ticket, err := auth.MakeRefreshToken()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to create ticket")
+		return
+	}
+
+	row, err := cfg.dbQueries.CreateWSTicket(r.Context(), database.CreateWSTicketParams{Ticket: ticket, UserID: userID})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to create ticket")
+		return
+	}
+
+	respondWithJson(w, http.StatusCreated, struct {
+		Ticket    string    `json:"ticket"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}{Ticket: row.Ticket, ExpiresAt: row.ExpiresAt})
+}
+
+// cleanupWSTickets periodically deletes the tickets that expired unused.
+func cleanupWSTickets(q *database.Queries, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := q.DeleteExpiredWSTickets(context.Background())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+}
+
+// checkWSOrigin allows upgrades from the API's own origin and the ones in
+// WS_ALLOWED_ORIGINS. Requests without an Origin don't come from a browser,
+// so they can't be cross-site.
+func (cfg *apiConfig) checkWSOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Host, r.Host) || slices.Contains(cfg.wsOrigins, origin)
+}
+
+// wsAuthenticate identifies the user opening a session, from a ticket or, for
+// clients that can set headers, the access token.
+func (cfg *apiConfig) wsAuthenticate(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	if ticket := r.URL.Query().Get("ticket"); ticket != "" {
+		userID, err := cfg.dbQueries.RedeemWSTicket(r.Context(), ticket)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusUnauthorized, "invalid ticket")
+			return uuid.UUID{}, false
+		} else if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			respondWithError(w, http.StatusInternalServerError, "failed to open session")
+			return uuid.UUID{}, false
+		}
+
+		return userID, true
+	}
+
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "no access token")
+		return uuid.UUID{}, false
+	}
+
+	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid access token")
+		return uuid.UUID{}, false
+	}
+
+	return userID, true
+}
+
+// wsHandler upgrades to a WebSocket session.
+func (cfg *apiConfig) wsHandler(w http.ResponseWriter, r *http.Request) {
+	// Checked before authenticating so a cross-site page can't use up a
+	// ticket.
+	if !cfg.checkWSOrigin(r) {
+		respondWithError(w, http.StatusForbidden, "origin not allowed")
+		return
+	}
+
+	userID, ok := cfg.wsAuthenticate(w, r)
+	if !ok {
+		return
+	}
+
+	key := "user:" + userID.String()
+	if !cfg.streams.acquire(key) {
+		respondWithError(w, http.StatusTooManyRequests, "too many open streams")
+		return
+	}
+	defer cfg.streams.release(key)
+
+	muted, err := cfg.mutedFilter(r.Context(), userID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to open session")
+		return
+	}
+
+	upgrader := websocket.Upgrader{CheckOrigin: cfg.checkWSOrigin}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	conn.SetReadLimit(wsMaxMessageSize)
+
+	c := &wsClient{
+		cfg:        cfg,
+		conn:       conn,
+		userID:     userID,
+		send:       make(chan []byte, wsSendBuffer),
+		done:       make(chan struct{}),
+		channels:   map[string]stream.Filter{},
+		muted:      muted,
+		lastTyping: map[uuid.UUID]time.Time{},
+	}
+
+	if !cfg.sockets.add(c) {
+		closeWS(conn, websocket.CloseGoingAway, "server shutting down")
+		return
+	}
+	defer cfg.sockets.remove(c)
+	defer c.close(websocket.CloseNormalClosure, "")
+
+	sub := cfg.broker.Subscribe(c.wants)
+	defer sub.Close()
+
+	go c.writeLoop()
+	go c.eventLoop(sub)
+
+	c.enqueue(wsMessage{Type: "welcome", Data: struct {
+		UserID   uuid.UUID `json:"user_id"`
+		Versions []int     `json:"versions"`
+	}{UserID: userID, Versions: []int{wsProtocolVersion}}})
+
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var req wsRequest
+		err = json.Unmarshal(message, &req)
+		if err != nil {
+			c.enqueue(wsMessage{Type: "error", Error: "invalid frame"})
+			continue
+		}
+
+		c.handle(req)
+	}
+}